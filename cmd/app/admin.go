@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/service"
+	"vk-film-library/pkg/logger"
+)
+
+const adminUsage = "usage: app admin create|promote <username>, the password of a created admin is read from stdin"
+
+// systemPrincipal is recorded in the audit log as the author of the admins
+// created or promoted by the app itself rather than over the API.
+var systemPrincipal = &entity.Principal{Username: "system"}
+
+// runAdmin runs the admin subcommand: create creates an admin with the password
+// read from the first line of in, promote makes an existing user an admin.
+func runAdmin(ctx context.Context, authService service.Auth, args []string, in io.Reader, out io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf(adminUsage)
+	}
+
+	ctx = service.WithPrincipal(ctx, systemPrincipal)
+	username := args[1]
+
+	switch args[0] {
+	case "create":
+		password, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		password = strings.TrimRight(password, "\r\n")
+
+		id, err := authService.CreateAdmin(ctx, &entity.CreateInput{Username: username, Password: password})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "created admin %s with id %d\n", username, id)
+	case "promote":
+		err := authService.PromoteUser(ctx, username)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "promoted %s to admin\n", username)
+	default:
+		return fmt.Errorf(adminUsage)
+	}
+
+	return nil
+}
+
+// bootstrapAdmin creates the admin from the config if there is no admin yet.
+func bootstrapAdmin(ctx context.Context, authService service.Auth, log *logger.Logger, username, password string) error {
+	if password == "" {
+		return fmt.Errorf("bootstrap admin %s has no password", username)
+	}
+
+	created, err := authService.BootstrapAdmin(service.WithPrincipal(ctx, systemPrincipal), &entity.CreateInput{
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		return fmt.Errorf("bootstrap admin %s: %v", username, err)
+	}
+	if created {
+		log.Infof("created bootstrap admin %s", username)
+	}
+
+	return nil
+}
@@ -9,12 +9,14 @@ import (
 	"os/signal"
 	"syscall"
 	"vk-film-library/config"
+	"vk-film-library/db"
 	_ "vk-film-library/docs"
 	v1 "vk-film-library/internal/controller/http/v1"
 	"vk-film-library/internal/httpserver"
 	"vk-film-library/internal/repo"
 	"vk-film-library/internal/service"
 	"vk-film-library/pkg/logger"
+	"vk-film-library/pkg/migrate"
 	"vk-film-library/pkg/postgres"
 )
 
@@ -45,17 +47,57 @@ func main() {
 	}
 	defer client.Close()
 
+	migrator, err := migrate.New(client, db.Migrations, "migrations")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(context.Background(), migrator, os.Args[2:], os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(pending) > 0 {
+		if cfg.Migrations.RequireLatest {
+			log.Fatalf("database schema is behind by %d migrations, run the migrate up command", len(pending))
+		}
+		log.Warnf("database schema is behind by %d migrations", len(pending))
+	}
+
 	log.Info("initializing repositories")
 	repos := repo.NewRepositories(client)
 
 	log.Info("initializing services")
 	deps := service.ServicesDependencies{
-		Repos:    repos,
-		SignKey:  cfg.JWT.SignKey,
-		TokenTTL: cfg.JWT.TokenTTL,
+		Repos:           repos,
+		SignKey:         cfg.JWT.SignKey,
+		TokenTTL:        cfg.JWT.TokenTTL,
+		RefreshTokenTTL: cfg.JWT.RefreshTokenTTL,
 	}
 	services := service.NewServices(deps)
 
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		err = runAdmin(context.Background(), services.Auth, os.Args[2:], os.Stdin, os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if cfg.BootstrapAdmin.Username != "" {
+		err = bootstrapAdmin(context.Background(), services.Auth, log, cfg.BootstrapAdmin.Username, cfg.BootstrapAdmin.Password)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	mux := http.NewServeMux()
 	v1.NewRouter(mux, services, log)
 	log.Info("starting http server")
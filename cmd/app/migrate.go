@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+	"vk-film-library/pkg/migrate"
+)
+
+const migrateUsage = "usage: app migrate up|down|status"
+
+// runMigrate runs the migrate subcommand: up applies all pending migrations,
+// down reverts the last applied one and status lists all migrations.
+func runMigrate(ctx context.Context, migrator *migrate.Migrator, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+	case "down":
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "reverted %03d_%s\n", m.Version, m.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if !s.AppliedAt.IsZero() {
+				appliedAt = "applied at " + s.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(out, "%03d_%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+	default:
+		return fmt.Errorf(migrateUsage)
+	}
+
+	return nil
+}
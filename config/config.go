@@ -11,6 +11,9 @@ type Config struct {
 	HTTPServer `yaml:"http_server"`
 	Postgres   `yaml:"postgres"`
 	JWT        `yaml:"jwt"`
+	Migrations `yaml:"migrations"`
+
+	BootstrapAdmin `yaml:"bootstrap_admin"`
 }
 
 type HTTPServer struct {
@@ -27,8 +30,21 @@ type Postgres struct {
 }
 
 type JWT struct {
-	SignKey  string        `yaml:"sign_key"`
-	TokenTTL time.Duration `yaml:"token_ttl"`
+	SignKey         string        `yaml:"sign_key"`
+	TokenTTL        time.Duration `yaml:"token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+}
+
+type Migrations struct {
+	// RequireLatest makes the server refuse to start while some migrations are not applied.
+	RequireLatest bool `yaml:"require_latest"`
+}
+
+// BootstrapAdmin is the first admin, created at startup if there is no admin
+// yet. The password is better passed in the environment than in the file.
+type BootstrapAdmin struct {
+	Username string `yaml:"username" env:"BOOTSTRAP_ADMIN_USERNAME"`
+	Password string `yaml:"password" env:"BOOTSTRAP_ADMIN_PASSWORD"`
 }
 
 var instance *Config
@@ -0,0 +1,9 @@
+// Package db holds the migrations of the database schema.
+package db
+
+import "embed"
+
+// Migrations are the numbered up and down migrations, e.g. 001_init.up.sql and 001_init.down.sql.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,4 @@
+drop table if exists films_actors;
+drop table if exists films;
+drop table if exists actors;
+drop table if exists users;
@@ -1,7 +1,9 @@
+-- Initial schema. Tables are created only if they do not exist, so that databases
+-- created from the former db/init.sql can be brought under migrations.
 create table if not exists users
 (
     id       int generated always as identity primary key,
-    username     text unique not null,
+    username text unique not null,
     password text not null,
     role     text not null
 );
@@ -31,4 +33,4 @@ create table if not exists films_actors
 
     foreign key (actor_id) references actors(id) on delete cascade,
     foreign key (film_id) references films(id) on delete cascade
-);
\ No newline at end of file
+);
@@ -0,0 +1,7 @@
+drop index if exists films_name_trgm_idx;
+drop index if exists actors_name_trgm_idx;
+
+alter table films drop column if exists search_en;
+alter table films drop column if exists search_ru;
+
+drop extension if exists pg_trgm;
@@ -0,0 +1,16 @@
+-- Adds full-text search over film names and descriptions and trigram indexes
+-- for the typo-tolerant search by name.
+create extension if not exists pg_trgm;
+
+alter table films add column if not exists search_ru tsvector generated always as (
+    setweight(to_tsvector('russian', name), 'A') || setweight(to_tsvector('russian', description), 'B')
+) stored;
+alter table films add column if not exists search_en tsvector generated always as (
+    setweight(to_tsvector('english', name), 'A') || setweight(to_tsvector('english', description), 'B')
+) stored;
+
+create index if not exists films_search_ru_idx on films using gin (search_ru);
+create index if not exists films_search_en_idx on films using gin (search_en);
+
+create index if not exists actors_name_trgm_idx on actors using gin (name gin_trgm_ops);
+create index if not exists films_name_trgm_idx on films using gin (name gin_trgm_ops);
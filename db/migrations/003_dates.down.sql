@@ -0,0 +1,2 @@
+alter table films alter column created_at type text using to_char(created_at, 'YYYY-MM-DD');
+alter table actors alter column birthday type text using to_char(birthday, 'YYYY-MM-DD');
@@ -0,0 +1,8 @@
+-- Converts actors.birthday and films.created_at from text to date.
+--
+-- The migration fails if some rows hold values that are not dates in format YYYY-MM-DD,
+-- they have to be fixed by hand first. Such rows can be found with
+--   select id, birthday from actors where birthday !~ '^\d{4}-\d{2}-\d{2}$';
+--   select id, created_at from films where created_at !~ '^\d{4}-\d{2}-\d{2}$';
+alter table actors alter column birthday type date using birthday::date;
+alter table films alter column created_at type date using created_at::date;
@@ -0,0 +1,2 @@
+drop table if exists films_genres;
+drop table if exists genres;
@@ -0,0 +1,19 @@
+-- Adds genres and the link between films and genres.
+create table if not exists genres
+(
+    id   int generated always as identity primary key,
+    name text unique not null
+);
+
+create table if not exists films_genres
+(
+    id       int generated always as identity primary key,
+    film_id  int not null,
+    genre_id int not null,
+
+    unique (film_id, genre_id),
+    foreign key (film_id) references films(id) on delete cascade,
+    foreign key (genre_id) references genres(id) on delete cascade
+);
+
+create index if not exists films_genres_genre_id_idx on films_genres (genre_id);
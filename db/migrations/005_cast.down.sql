@@ -0,0 +1,3 @@
+alter table films_actors drop column if exists credit_type;
+alter table films_actors drop column if exists billing;
+alter table films_actors drop column if exists character;
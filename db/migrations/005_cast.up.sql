@@ -0,0 +1,10 @@
+-- Adds the character name, billing position and credit type to film casts.
+-- Billing of existing casts follows the order in which the actors were added.
+alter table films_actors add column if not exists character text not null default '';
+alter table films_actors add column if not exists billing int not null default 0;
+alter table films_actors add column if not exists credit_type text not null default 'supporting'
+    check (credit_type in ('lead', 'supporting', 'cameo', 'voice'));
+
+update films_actors fa set billing = numbered.billing
+from (select id, row_number() over (partition by film_id order by id) as billing from films_actors) numbered
+where fa.id = numbered.id and fa.billing = 0;
@@ -0,0 +1,15 @@
+-- Adds film crews: people who worked on films as directors, writers, producers or composers.
+-- People of any role are stored in actors.
+create table if not exists films_crew
+(
+    id        int generated always as identity primary key,
+    film_id   int not null,
+    person_id int not null,
+    role      text not null check (role in ('director', 'writer', 'producer', 'composer')),
+
+    unique (film_id, person_id, role),
+    foreign key (person_id) references actors(id) on delete cascade,
+    foreign key (film_id) references films(id) on delete cascade
+);
+
+create index if not exists films_crew_person_id_idx on films_crew (person_id);
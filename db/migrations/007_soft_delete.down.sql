@@ -0,0 +1,9 @@
+-- Rows in the trash are purged, as without deleted_at they would become visible again.
+delete from films where deleted_at is not null;
+delete from actors where deleted_at is not null;
+
+drop index if exists films_deleted_at_idx;
+drop index if exists actors_deleted_at_idx;
+
+alter table films drop column if exists deleted_at;
+alter table actors drop column if exists deleted_at;
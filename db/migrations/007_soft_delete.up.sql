@@ -0,0 +1,6 @@
+-- Adds soft deletion of films and actors, deleted rows stay in the trash until purged.
+alter table films add column if not exists deleted_at timestamptz;
+alter table actors add column if not exists deleted_at timestamptz;
+
+create index if not exists films_deleted_at_idx on films (deleted_at) where deleted_at is not null;
+create index if not exists actors_deleted_at_idx on actors (deleted_at) where deleted_at is not null;
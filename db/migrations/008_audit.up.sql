@@ -0,0 +1,18 @@
+-- Adds the audit log of changes made by admins. user_id has no foreign key,
+-- so that entries outlive the users who made them.
+create table if not exists audit_log
+(
+    id          int generated always as identity primary key,
+    user_id     int not null,
+    username    text not null,
+    action      text not null,
+    entity_type text not null,
+    entity_id   int not null,
+    created_at  timestamptz not null default now(),
+    before      jsonb,
+    after       jsonb
+);
+
+create index if not exists audit_log_entity_idx on audit_log (entity_type, entity_id);
+create index if not exists audit_log_user_id_idx on audit_log (user_id);
+create index if not exists audit_log_created_at_idx on audit_log (created_at);
@@ -0,0 +1,14 @@
+-- Adds the revision history of films and actors. data is the editable state of
+-- the entity, the film cast refers to actors by name.
+create table if not exists revisions
+(
+    id          int generated always as identity primary key,
+    entity_type text not null,
+    entity_id   int not null,
+    number      int not null,
+    user_id     int not null,
+    username    text not null,
+    created_at  timestamptz not null default now(),
+    data        jsonb not null,
+    unique (entity_type, entity_id, number)
+);
@@ -0,0 +1,22 @@
+-- Adds sign-in sessions and their refresh tokens. Only SHA-256 hashes of the
+-- refresh tokens are stored, a used token is kept to detect its reuse.
+create table if not exists sessions
+(
+    id         int generated always as identity primary key,
+    user_id    int not null references users (id) on delete cascade,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    revoked_at timestamptz
+);
+
+create index if not exists sessions_user_id_idx on sessions (user_id);
+
+create table if not exists refresh_tokens
+(
+    token_hash text primary key,
+    session_id int not null references sessions (id) on delete cascade,
+    created_at timestamptz not null default now(),
+    used_at    timestamptz
+);
+
+create index if not exists refresh_tokens_session_id_idx on refresh_tokens (session_id);
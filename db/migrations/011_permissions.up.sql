@@ -0,0 +1,26 @@
+-- Adds the permissions of user roles. A role is configured by its rows, a role
+-- without rows has no permissions. The rows reproduce the former hardcoded checks.
+create table if not exists role_permissions
+(
+    role       text not null,
+    permission text not null,
+    primary key (role, permission)
+);
+
+insert into role_permissions (role, permission)
+values ('admin', 'films:read'),
+       ('admin', 'films:write'),
+       ('admin', 'films:delete'),
+       ('admin', 'actors:read'),
+       ('admin', 'actors:write'),
+       ('admin', 'actors:delete'),
+       ('admin', 'genres:read'),
+       ('admin', 'genres:write'),
+       ('admin', 'genres:delete'),
+       ('admin', 'audit:read'),
+       ('admin', 'revisions:read'),
+       ('admin', 'admins:write'),
+       ('user', 'films:read'),
+       ('user', 'actors:read'),
+       ('user', 'genres:read')
+on conflict do nothing;
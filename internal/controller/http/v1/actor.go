@@ -1,7 +1,6 @@
 package v1
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -21,10 +20,18 @@ func newActorRoutes(mux *http.ServeMux, actorService service.Actor, middleware *
 		log:          log,
 	}
 
-	mux.HandleFunc("/api/v1/actors/create", middleware.RequireAuth(ar.createActor))
-	mux.HandleFunc("/api/v1/actors", middleware.RequireAuth(ar.getAllActors))
-	mux.HandleFunc("/api/v1/actors/edit", middleware.RequireAuth(ar.editActor))
-	mux.HandleFunc("/api/v1/actors/delete/{id}", middleware.RequireAuth(ar.deleteActor))
+	mux.HandleFunc("/api/v1/actors/create", middleware.RequirePermission(entity.PermActorsWrite, ar.createActor))
+	mux.HandleFunc("/api/v1/actors/{id}", middleware.RequirePermission(entity.PermActorsRead, ar.getActorByID))
+	mux.HandleFunc("/api/v1/actors", middleware.RequirePermission(entity.PermActorsRead, ar.getAllActors))
+	mux.HandleFunc("/api/v1/actors/find", middleware.RequirePermission(entity.PermActorsRead, ar.findActors))
+	mux.HandleFunc("/api/v1/actors/edit", middleware.RequirePermission(entity.PermActorsWrite, ar.editActor))
+	mux.HandleFunc("/api/v1/actors/delete/{id}", middleware.RequirePermission(entity.PermActorsDelete, ar.deleteActor))
+	mux.HandleFunc("/api/v1/actors/trash", middleware.RequirePermission(entity.PermActorsDelete, ar.getDeletedActors))
+	mux.HandleFunc("/api/v1/actors/restore/{id}", middleware.RequirePermission(entity.PermActorsDelete, ar.restoreActor))
+	mux.HandleFunc("/api/v1/actors/purge/{id}", middleware.RequirePermission(entity.PermActorsDelete, ar.purgeActor))
+	mux.HandleFunc("/api/v1/actors/revisions/{id}", middleware.RequirePermission(entity.PermRevisionsRead, ar.getActorRevisions))
+	mux.HandleFunc("/api/v1/actors/revisions/{id}/diff", middleware.RequirePermission(entity.PermRevisionsRead, ar.diffActorRevisions))
+	mux.HandleFunc("/api/v1/actors/revert/{id}", middleware.RequirePermission(entity.PermActorsWrite, ar.revertActor))
 }
 
 // @Summary Create actor
@@ -34,34 +41,28 @@ func newActorRoutes(mux *http.ServeMux, actorService service.Actor, middleware *
 // @Accept json
 // @Produce json
 // @Success 201 {object} v1.actorRoutes.createActor.response
-// @Failure 400 {string} error
-// @Failure 500 {string} error
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
 // @Security JWT
 // @Router /api/v1/actors/create [post]
 func (ar *actorRoutes) createActor(w http.ResponseWriter, req *http.Request) {
 	if req.Method != "POST" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
-		return
-	}
-
-	role := req.Header.Get(userRoleHeader)
-	if role != "admin" {
-		ar.log.Error("actorRoutes CreateActor: user does not have the necessary rights")
-		http.Error(w, "you do not have the necessary rights", http.StatusBadRequest)
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
 	var input entity.ActorCreateInput
 	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
 		ar.log.Errorf("actorRoutes CreateActor: invalid request body %v", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeError(w, ErrInvalidRequestBody)
 		return
 	}
 
-	id, err := ar.actorService.CreateActor(context.Background(), &input)
+	id, err := ar.actorService.CreateActor(req.Context(), &input)
 	if err != nil {
 		ar.log.Errorf("actorRoutes CreateActor: actorService.CreateActor %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -69,56 +70,144 @@ func (ar *actorRoutes) createActor(w http.ResponseWriter, req *http.Request) {
 		Id int `json:"id"`
 	}
 
-	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	jsonResp, err := json.Marshal(response{Id: id})
 	if err != nil {
 		ar.log.Errorf("actorRoutes CreateActor: cannot marshal response %v", err)
-		http.Error(w, "cannot marshal response", http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Get actor
+// @Description Get actor with filmography sorted by release date. Films the person worked on
+// @Description as a director, writer, producer or composer are returned in credits.
+// @Tags actors
+// @Param id path integer true "Actor id"
+// @Produce json
+// @Success 200 {object} v1.actorRoutes.getActorByID.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/actors/{id} [get]
+func (ar *actorRoutes) getActorByID(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		ar.log.Errorf("actorRoutes GetActorByID: cannot get actor id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get actor id")
+		return
+	}
+
+	actor, err := ar.actorService.GetActorByID(req.Context(), id)
+	if err != nil {
+		ar.log.Errorf("actorRoutes GetActorByID: actorService.GetActorByID %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Actor *entity.Actor `json:"actor"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Actor: actor})
+	if err != nil {
+		ar.log.Errorf("actorRoutes GetActorByID: cannot marshal response %v", err)
+		writeError(w, err)
 		return
 	}
 	w.Write(jsonResp)
 }
 
 // @Summary Get all actors
-// @Description Get all actors
+// @Description Get all actors page by page
 // @Tags actors
+// @Param limit query integer false "page size, 20 by default, 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Produce json
 // @Success 200 {object} v1.actorRoutes.getAllActors.response
-// @Failure 400 {string} error
-// @Failure 500 {string} error
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
 // @Security JWT
 // @Router /api/v1/actors [get]
 func (ar *actorRoutes) getAllActors(w http.ResponseWriter, req *http.Request) {
 	if req.Method != "GET" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
-	role := req.Header.Get(userRoleHeader)
-	if role != "admin" && role != "user" {
-		ar.log.Errorf("actorRoutes GetAllActors: user does not have the necessary rights %s", role)
-		http.Error(w, "you do not have the necessary rights", http.StatusBadRequest)
+	page, err := getPage(req)
+	if err != nil {
+		ar.log.Errorf("actorRoutes GetAllActors: invalid page %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidPageLimit, "invalid page limit")
 		return
 	}
 
-	actors, err := ar.actorService.GetAllActors(context.Background())
+	actors, nextCursor, err := ar.actorService.GetAllActors(req.Context(), page)
 	if err != nil {
 		ar.log.Errorf("actorRoutes GetAllActors: actorService.GetAllActors %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	type response struct {
-		Actors []*entity.Actor `json:"actors"`
+		Actors     []*entity.Actor `json:"actors"`
+		NextCursor string          `json:"next_cursor,omitempty"`
 	}
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
-	jsonResp, err := json.Marshal(response{Actors: actors})
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Actors: actors, NextCursor: nextCursor})
 	if err != nil {
 		ar.log.Errorf("actorRoutes GetAllActors: cannot marshal response %v", err)
-		http.Error(w, "cannot marshal response", http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Find actors by name
+// @Description Find actors whose name contains the passed one, ordered by similarity.
+// @Description When there are no such actors, similar names are returned in did_you_mean.
+// @Tags actors
+// @Param name query string true "actor name"
+// @Produce json
+// @Success 200 {object} entity.NameSearchResult
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/actors/find [get]
+func (ar *actorRoutes) findActors(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	result, err := ar.actorService.FindActors(req.Context(), req.URL.Query().Get("name"))
+	if err != nil {
+		ar.log.Errorf("actorRoutes FindActors: actorService.FindActors %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(result)
+	if err != nil {
+		ar.log.Errorf("actorRoutes FindActors: cannot marshal response %v", err)
+		writeError(w, err)
 		return
 	}
 	w.Write(jsonResp)
@@ -127,42 +216,32 @@ func (ar *actorRoutes) getAllActors(w http.ResponseWriter, req *http.Request) {
 // @Summary Edit actor
 // @Description Edit actor
 // @Tags actors
-// @Param input body entity.Actor true "information about actor"
+// @Param input body entity.ActorEditInput true "information about actor, only set fields are changed"
 // @Accept json
 // @Success 200
-// @Failure 400 {string} error
-// @Failure 404 {string} error
-// @Failure 500 {string} error
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
 // @Security JWT
 // @Router /api/v1/actors/edit [put]
 func (ar *actorRoutes) editActor(w http.ResponseWriter, req *http.Request) {
 	if req.Method != "PUT" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
-		return
-	}
-
-	role := req.Header.Get(userRoleHeader)
-	if role != "admin" {
-		ar.log.Error("actorRoutes EditActor: user does not have the necessary rights")
-		http.Error(w, "you do not have the necessary rights", http.StatusBadRequest)
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
-	var input entity.Actor
+	var input entity.ActorEditInput
 	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
 		ar.log.Errorf("actorRoutes EditActor: invalid request body %v", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeError(w, ErrInvalidRequestBody)
 		return
 	}
 
-	err := ar.actorService.EditActor(context.Background(), &input)
+	err := ar.actorService.EditActor(req.Context(), &input)
 	if err != nil {
 		ar.log.Errorf("actorRoutes EditActor: actorService.EditActor %v", err)
-		if err == service.ErrActorNotFound {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -170,43 +249,285 @@ func (ar *actorRoutes) editActor(w http.ResponseWriter, req *http.Request) {
 }
 
 // @Summary Delete actor
-// @Description Delete actor
+// @Description Move actor to the trash, it can be restored or purged later
 // @Tags actors
 // @Param id path integer true "Actor id"
 // @Success 200
-// @Failure 400 {string} error
-// @Failure 404 {string} error
-// @Failure 500 {string} error
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
 // @Security JWT
 // @Router /api/v1/actors/delete/{id} [delete]
 func (ar *actorRoutes) deleteActor(w http.ResponseWriter, req *http.Request) {
 	if req.Method != "DELETE" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		ar.log.Errorf("actorRoutes DeleteActor: cannot get actor id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get actor id")
+		return
+	}
+
+	err = ar.actorService.DeleteActor(req.Context(), id)
+	if err != nil {
+		ar.log.Errorf("actorRoutes DeleteActor: actorService.DeleteActor %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Get deleted actors
+// @Description Get actors in the trash, recently deleted first
+// @Tags actors
+// @Produce json
+// @Success 200 {object} v1.actorRoutes.getDeletedActors.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/actors/trash [get]
+func (ar *actorRoutes) getDeletedActors(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	actors, err := ar.actorService.GetDeletedActors(req.Context())
+	if err != nil {
+		ar.log.Errorf("actorRoutes GetDeletedActors: actorService.GetDeletedActors %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Actors []*entity.TrashItem `json:"actors"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Actors: actors})
+	if err != nil {
+		ar.log.Errorf("actorRoutes GetDeletedActors: cannot marshal response %v", err)
+		writeError(w, err)
 		return
 	}
+	w.Write(jsonResp)
+}
 
-	role := req.Header.Get(userRoleHeader)
-	if role != "admin" {
-		ar.log.Error("actorRoutes DeleteActor: user does not have the necessary rights")
-		http.Error(w, "you do not have the necessary rights", http.StatusBadRequest)
+// @Summary Restore actor
+// @Description Restore actor from the trash together with the actor's credits
+// @Tags actors
+// @Param id path integer true "Actor id"
+// @Success 200
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/actors/restore/{id} [post]
+func (ar *actorRoutes) restoreActor(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
 	id, err := strconv.Atoi(req.PathValue("id"))
 	if err != nil {
-		ar.log.Errorf("actorRoutes DeleteActor: cannot get actor id %v", err)
-		http.Error(w, "cannot get actor id", http.StatusBadRequest)
+		ar.log.Errorf("actorRoutes RestoreActor: cannot get actor id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get actor id")
 		return
 	}
 
-	err = ar.actorService.DeleteActor(context.Background(), id)
+	err = ar.actorService.RestoreActor(req.Context(), id)
 	if err != nil {
-		ar.log.Errorf("actorRoutes DeleteActor: actorService.DeleteActor %v", err)
-		if err == service.ErrActorNotFound {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		ar.log.Errorf("actorRoutes RestoreActor: actorService.RestoreActor %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Purge actor
+// @Description Permanently delete actor from the trash
+// @Tags actors
+// @Param id path integer true "Actor id"
+// @Success 200
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/actors/purge/{id} [delete]
+func (ar *actorRoutes) purgeActor(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "DELETE" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		ar.log.Errorf("actorRoutes PurgeActor: cannot get actor id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get actor id")
+		return
+	}
+
+	err = ar.actorService.PurgeActor(req.Context(), id)
+	if err != nil {
+		ar.log.Errorf("actorRoutes PurgeActor: actorService.PurgeActor %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Get actor revisions
+// @Description Get revisions of actor without their data, most recent first
+// @Tags actors
+// @Param id path integer true "Actor id"
+// @Produce json
+// @Success 200 {object} v1.actorRoutes.getActorRevisions.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/actors/revisions/{id} [get]
+func (ar *actorRoutes) getActorRevisions(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		ar.log.Errorf("actorRoutes GetActorRevisions: cannot get actor id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get actor id")
+		return
+	}
+
+	revisions, err := ar.actorService.GetActorRevisions(req.Context(), id)
+	if err != nil {
+		ar.log.Errorf("actorRoutes GetActorRevisions: actorService.GetActorRevisions %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Revisions []*entity.Revision `json:"revisions"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Revisions: revisions})
+	if err != nil {
+		ar.log.Errorf("actorRoutes GetActorRevisions: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Diff actor revisions
+// @Description Get fields of actor changed from one revision to another
+// @Tags actors
+// @Param id path integer true "Actor id"
+// @Param from query integer true "number of the older revision"
+// @Param to query integer true "number of the newer revision"
+// @Produce json
+// @Success 200 {object} entity.RevisionDiff
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/actors/revisions/{id}/diff [get]
+func (ar *actorRoutes) diffActorRevisions(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		ar.log.Errorf("actorRoutes DiffActorRevisions: cannot get actor id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get actor id")
+		return
+	}
+
+	from, err := getRevisionNumber(req, "from")
+	if err != nil {
+		ar.log.Errorf("actorRoutes DiffActorRevisions: %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+	to, err := getRevisionNumber(req, "to")
+	if err != nil {
+		ar.log.Errorf("actorRoutes DiffActorRevisions: %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	diff, err := ar.actorService.DiffActorRevisions(req.Context(), id, from, to)
+	if err != nil {
+		ar.log.Errorf("actorRoutes DiffActorRevisions: actorService.DiffActorRevisions %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(diff)
+	if err != nil {
+		ar.log.Errorf("actorRoutes DiffActorRevisions: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Revert actor
+// @Description Bring actor back to the state of a revision, recorded as a new revision
+// @Tags actors
+// @Param id path integer true "Actor id"
+// @Param revision query integer true "number of the revision"
+// @Success 200
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/actors/revert/{id} [post]
+func (ar *actorRoutes) revertActor(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		ar.log.Errorf("actorRoutes RevertActor: cannot get actor id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get actor id")
+		return
+	}
+
+	number, err := getRevisionNumber(req, "revision")
+	if err != nil {
+		ar.log.Errorf("actorRoutes RevertActor: %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	err = ar.actorService.RevertActor(req.Context(), id, number)
+	if err != nil {
+		ar.log.Errorf("actorRoutes RevertActor: actorService.RevertActor %v", err)
+		writeError(w, err)
 		return
 	}
 
@@ -0,0 +1,116 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/service"
+	"vk-film-library/pkg/logger"
+)
+
+type adminRoutes struct {
+	authService service.Auth
+	log         *logger.Logger
+}
+
+func newAdminRoutes(mux *http.ServeMux, authService service.Auth, middleware *AuthMiddleware, log *logger.Logger) {
+	ar := &adminRoutes{
+		authService: authService,
+		log:         log,
+	}
+
+	mux.HandleFunc("/api/v1/admins/create", middleware.RequirePermission(entity.PermAdminsWrite, ar.createAdmin))
+	mux.HandleFunc("/api/v1/admins/promote", middleware.RequirePermission(entity.PermAdminsWrite, ar.promoteUser))
+}
+
+type promoteInput struct {
+	Username string `json:"username"`
+}
+
+// @Summary Create admin
+// @Description Create admin, the creation is recorded in the audit log
+// @Tags admins
+// @Accept json
+// @Produce json
+// @Param input body signInput true "input"
+// @Success 201 {object} v1.adminRoutes.createAdmin.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 409 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/admins/create [post]
+func (ar *adminRoutes) createAdmin(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	var input signInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		ar.log.Errorf("adminRoutes CreateAdmin: invalid request body %v", err)
+		writeError(w, ErrInvalidRequestBody)
+		return
+	}
+
+	id, err := ar.authService.CreateAdmin(req.Context(), &entity.CreateInput{
+		Username: input.Username,
+		Password: input.Password,
+	})
+	if err != nil {
+		ar.log.Errorf("adminRoutes CreateAdmin: authService.CreateAdmin %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Id int `json:"id"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	jsonResp, err := json.Marshal(response{Id: id})
+	if err != nil {
+		ar.log.Errorf("adminRoutes CreateAdmin: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Promote user
+// @Description Make an existing user an admin, the promotion is recorded in the audit log.
+// @Description The user gets the new role with the next refreshed access token.
+// @Tags admins
+// @Accept json
+// @Param input body promoteInput true "input"
+// @Success 200
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 409 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/admins/promote [post]
+func (ar *adminRoutes) promoteUser(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	var input promoteInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		ar.log.Errorf("adminRoutes PromoteUser: invalid request body %v", err)
+		writeError(w, ErrInvalidRequestBody)
+		return
+	}
+
+	err := ar.authService.PromoteUser(req.Context(), input.Username)
+	if err != nil {
+		ar.log.Errorf("adminRoutes PromoteUser: authService.PromoteUser %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/service"
+	"vk-film-library/pkg/logger"
+)
+
+type auditRoutes struct {
+	auditService service.Audit
+	log          *logger.Logger
+}
+
+func newAuditRoutes(mux *http.ServeMux, auditService service.Audit, middleware *AuthMiddleware, log *logger.Logger) {
+	ar := &auditRoutes{
+		auditService: auditService,
+		log:          log,
+	}
+
+	mux.HandleFunc("/api/v1/audit", middleware.RequirePermission(entity.PermAuditRead, ar.getAuditLog))
+}
+
+// @Summary Get audit log
+// @Description Get changes of films and actors made by admins page by page, most recent first
+// @Tags audit
+// @Param entity_type query string false "film or actor"
+// @Param entity_id query integer false "entity id, requires entity_type"
+// @Param user_id query integer false "id of the user who made the changes"
+// @Param from query string false "earliest time of a change, RFC 3339"
+// @Param to query string false "latest time of a change, RFC 3339"
+// @Param limit query integer false "page size, 20 by default, 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Produce json
+// @Success 200 {object} v1.auditRoutes.getAuditLog.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/audit [get]
+func (ar *auditRoutes) getAuditLog(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	filter, err := getAuditFilter(req)
+	if err != nil {
+		ar.log.Errorf("auditRoutes GetAuditLog: invalid filter %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	page, err := getPage(req)
+	if err != nil {
+		ar.log.Errorf("auditRoutes GetAuditLog: invalid page %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidPageLimit, "invalid page limit")
+		return
+	}
+
+	entries, nextCursor, err := ar.auditService.GetAuditLog(req.Context(), filter, page)
+	if err != nil {
+		ar.log.Errorf("auditRoutes GetAuditLog: auditService.GetAuditLog %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Entries    []*entity.AuditEntry `json:"entries"`
+		NextCursor string               `json:"next_cursor,omitempty"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Entries: entries, NextCursor: nextCursor})
+	if err != nil {
+		ar.log.Errorf("auditRoutes GetAuditLog: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// getAuditFilter reads the audit log filter from the query parameters.
+func getAuditFilter(req *http.Request) (*entity.AuditFilter, error) {
+	query := req.URL.Query()
+	filter := &entity.AuditFilter{
+		EntityType: query.Get("entity_type"),
+	}
+
+	if entityId := query.Get("entity_id"); entityId != "" {
+		id, err := strconv.Atoi(entityId)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entity_id")
+		}
+		filter.EntityId = id
+	}
+	if userId := query.Get("user_id"); userId != "" {
+		id, err := strconv.Atoi(userId)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user_id")
+		}
+		filter.UserId = id
+	}
+	if from := query.Get("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from: %v", err)
+		}
+		filter.From = t
+	}
+	if to := query.Get("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to: %v", err)
+		}
+		filter.To = t
+	}
+
+	return filter, nil
+}
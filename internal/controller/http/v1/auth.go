@@ -1,7 +1,6 @@
 package v1
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
 	"vk-film-library/internal/entity"
@@ -14,7 +13,7 @@ type authRoutes struct {
 	log         *logger.Logger
 }
 
-func newAuthRoutes(mux *http.ServeMux, authService service.Auth, log *logger.Logger) {
+func newAuthRoutes(mux *http.ServeMux, authService service.Auth, middleware *AuthMiddleware, log *logger.Logger) {
 	ar := &authRoutes{
 		authService: authService,
 		log:         log,
@@ -22,7 +21,8 @@ func newAuthRoutes(mux *http.ServeMux, authService service.Auth, log *logger.Log
 
 	mux.HandleFunc("/signin", ar.signIn)
 	mux.HandleFunc("/signup", ar.signUpUser)
-	mux.HandleFunc("/admin/signup", ar.signUpAdmin)
+	mux.HandleFunc("/token/refresh", ar.refreshToken)
+	mux.HandleFunc("/logout", middleware.RequireAuth(ar.logout))
 }
 
 type signInput struct {
@@ -30,6 +30,10 @@ type signInput struct {
 	Password string `json:"password" validate:"required,password"`
 }
 
+type refreshInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 // @Summary Sign up for user
 // @Description Sign up for user
 // @Tags auth
@@ -37,34 +41,31 @@ type signInput struct {
 // @Produce json
 // @Param input body signInput true "input"
 // @Success 201 {object} v1.authRoutes.signUpUser.response
-// @Failure 400 {string} error
-// @Failure 500 {string} error
+// @Failure 400 {object} v1.problem
+// @Failure 409 {object} v1.problem
+// @Failure 500 {object} v1.problem
 // @Router /signup [post]
 func (ar *authRoutes) signUpUser(w http.ResponseWriter, req *http.Request) {
 	if req.Method != "POST" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
 	var input signInput
 	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
 		ar.log.Errorf("authRoutes signUpUser: invalid request body %v", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeError(w, ErrInvalidRequestBody)
 		return
 	}
 
-	id, err := ar.authService.CreateUser(context.Background(), &entity.CreateInput{
+	id, err := ar.authService.CreateUser(req.Context(), &entity.CreateInput{
 		Username: input.Username,
 		Password: input.Password,
-		Role:     "user",
+		Role:     entity.UserRoleUser,
 	})
 	if err != nil {
 		ar.log.Errorf("authRoutes signUpUser: authService.CreateUser %v", err)
-		if err == service.ErrUserAlreadyExists {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -72,118 +73,143 @@ func (ar *authRoutes) signUpUser(w http.ResponseWriter, req *http.Request) {
 		Id int `json:"id"`
 	}
 
-	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	jsonResp, err := json.Marshal(response{Id: id})
 	if err != nil {
 		ar.log.Errorf("authRoutes signUpUser: cannot marshal response %v", err)
-		http.Error(w, "cannot marshal response", http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 	w.Write(jsonResp)
 }
 
-// @Summary Sign up for admin
-// @Description Sign up for admin
+// @Summary Sign in
+// @Description Sign in and get an access token and a refresh token of a new session
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param input body signInput true "input"
-// @Success 201 {object} v1.authRoutes.signUpAdmin.response
-// @Failure 400 {string} error
-// @Failure 500 {string} error
-// @Router /admin/signup [post]
-func (ar *authRoutes) signUpAdmin(w http.ResponseWriter, req *http.Request) {
+// @Success 200 {object} v1.authRoutes.signIn.response
+// @Failure 400 {object} v1.problem
+// @Failure 401 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Router /signin [post]
+func (ar *authRoutes) signIn(w http.ResponseWriter, req *http.Request) {
 	if req.Method != "POST" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
 	var input signInput
 	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
-		ar.log.Errorf("authRoutes signUpAdmin: invalid request body %v", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		ar.log.Errorf("authRoutes signUpIn: invalid request body %v", err)
+		writeError(w, ErrInvalidRequestBody)
 		return
 	}
 
-	id, err := ar.authService.CreateUser(context.Background(), &entity.CreateInput{
+	tokens, err := ar.authService.GenerateToken(req.Context(), &entity.AuthInput{
 		Username: input.Username,
 		Password: input.Password,
-		Role:     "admin",
 	})
 	if err != nil {
-		ar.log.Errorf("authRoutes signUpAdmin: authService.CreateUser %v", err)
-		if err == service.ErrUserAlreadyExists {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		ar.log.Errorf("authRoutes signIn: authService.GenerateToken %v", err)
+		writeError(w, err)
 		return
 	}
 
 	type response struct {
-		Id int `json:"id"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
-	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
-	jsonResp, err := json.Marshal(response{Id: id})
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken})
 	if err != nil {
-		ar.log.Errorf("authRoutes signUpAdmin: cannot marshal response %v", err)
-		http.Error(w, "cannot marshal response", http.StatusInternalServerError)
+		ar.log.Errorf("authRoutes signIn: cannot marshal response %v", err)
+		writeError(w, err)
 		return
 	}
 	w.Write(jsonResp)
 }
 
-// @Summary Sign in
-// @Description Sign in
+// @Summary Refresh token
+// @Description Exchange a refresh token for a new access token and a new refresh token.
+// @Description A refresh token can be used once, using it again revokes the session.
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param input body signInput true "input"
-// @Success 200 {object} v1.authRoutes.signIn.response
-// @Failure 400 {string} error
-// @Failure 500 {string} error
-// @Router /signin [post]
-func (ar *authRoutes) signIn(w http.ResponseWriter, req *http.Request) {
+// @Param input body refreshInput true "input"
+// @Success 200 {object} v1.authRoutes.refreshToken.response
+// @Failure 400 {object} v1.problem
+// @Failure 401 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Router /token/refresh [post]
+func (ar *authRoutes) refreshToken(w http.ResponseWriter, req *http.Request) {
 	if req.Method != "POST" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
-	var input signInput
+	var input refreshInput
 	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
-		ar.log.Errorf("authRoutes signUpIn: invalid request body %v", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		ar.log.Errorf("authRoutes refreshToken: invalid request body %v", err)
+		writeError(w, ErrInvalidRequestBody)
 		return
 	}
 
-	token, err := ar.authService.GenerateToken(context.Background(), &entity.AuthInput{
-		Username: input.Username,
-		Password: input.Password,
-	})
+	tokens, err := ar.authService.RefreshToken(req.Context(), input.RefreshToken)
 	if err != nil {
-		ar.log.Errorf("authRoutes signIn: authService.GenerateToken %v", err)
-		if err == service.ErrUserNotFound {
-			http.Error(w, "invalid username or password", http.StatusBadRequest)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		ar.log.Errorf("authRoutes refreshToken: authService.RefreshToken %v", err)
+		writeError(w, err)
 		return
 	}
 
 	type response struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
-	jsonResp, err := json.Marshal(response{Token: token})
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken})
 	if err != nil {
-		ar.log.Errorf("authRoutes signIn: cannot marshal response %v", err)
-		http.Error(w, "cannot marshal response", http.StatusInternalServerError)
+		ar.log.Errorf("authRoutes refreshToken: cannot marshal response %v", err)
+		writeError(w, err)
 		return
 	}
 	w.Write(jsonResp)
 }
+
+// @Summary Log out
+// @Description Revoke the session of the access token together with its refresh token
+// @Tags auth
+// @Success 200
+// @Failure 400 {object} v1.problem
+// @Failure 401 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /logout [post]
+func (ar *authRoutes) logout(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	principal, ok := service.PrincipalFromContext(req.Context())
+	if !ok {
+		ar.log.Error("authRoutes logout: no principal in request context")
+		writeError(w, ErrCannotParseToken)
+		return
+	}
+
+	err := ar.authService.Logout(req.Context(), principal.SessionId)
+	if err != nil {
+		ar.log.Errorf("authRoutes logout: authService.Logout %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
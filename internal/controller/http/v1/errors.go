@@ -1,8 +1,184 @@
 package v1
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo/repoerrs"
+	"vk-film-library/internal/service"
+)
 
 var (
-	ErrInvalidAuthHeader = fmt.Errorf("invalid auth header")
-	ErrCannotParseToken  = fmt.Errorf("cannot parse token")
+	ErrInvalidAuthHeader  = fmt.Errorf("invalid auth header")
+	ErrCannotParseToken   = fmt.Errorf("cannot parse token")
+	ErrIncorrectMethod    = fmt.Errorf("incorrect http method")
+	ErrNoRights           = fmt.Errorf("you do not have the necessary rights")
+	ErrInvalidRequestBody = fmt.Errorf("invalid request body")
+)
+
+// Error codes of problem responses. Clients rely on them, so a code must never be
+// changed once released.
+const (
+	CodeInvalidMethod      = "invalid_method"
+	CodeInvalidRequestBody = "invalid_request_body"
+	CodeInvalidParameter   = "invalid_parameter"
+	CodeValidationFailed   = "validation_failed"
+	CodeInvalidAuthHeader  = "invalid_auth_header"
+	CodeInvalidToken       = "invalid_token"
+	CodeInvalidRefresh     = "invalid_refresh_token"
+	CodeRefreshTokenReused = "refresh_token_reused"
+	CodeNoRights           = "no_rights"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeUserAlreadyExists  = "user_already_exists"
+	CodeUserNotFound       = "user_not_found"
+	CodeUserAlreadyAdmin   = "user_already_admin"
+	CodeActorNotFound      = "actor_not_found"
+	CodeFilmNotFound       = "film_not_found"
+	CodeGenreNotFound      = "genre_not_found"
+	CodeActorNotInTrash    = "actor_not_in_trash"
+	CodeFilmNotInTrash     = "film_not_in_trash"
+	CodeRevisionNotFound   = "revision_not_found"
+	CodeGenreAlreadyExists = "genre_already_exists"
+	CodeUnknownActors      = "unknown_actors"
+	CodeAmbiguousActors    = "ambiguous_actors"
+	CodeUnknownGenres      = "unknown_genres"
+	CodeInvalidFilmFilter  = "invalid_film_filter"
+	CodeInvalidFilmSearch  = "invalid_film_search"
+	CodeInvalidAuditFilter = "invalid_audit_filter"
+	CodeEmptyName          = "empty_name"
+	CodeInvalidCursor      = "invalid_cursor"
+	CodeInvalidPageLimit   = "invalid_page_limit"
+	CodeNotFound           = "not_found"
+	CodeAlreadyExists      = "already_exists"
+	CodeInternal           = "internal_error"
 )
+
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix makes a problem type URI out of an error code.
+const problemTypePrefix = "urn:vk-film-library:problem:"
+
+// problem is an error response in the RFC 7807 format extended with a stable
+// error code and the invalid fields of a request.
+type problem struct {
+	Type   string              `json:"type"`
+	Title  string              `json:"title"`
+	Status int                 `json:"status"`
+	Detail string              `json:"detail,omitempty"`
+	Code   string              `json:"code"`
+	Errors []entity.FieldError `json:"errors,omitempty"`
+}
+
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// errorMappings maps the known sentinel errors to problems. An error matches
+// the first mapping it wraps.
+var errorMappings = []errorMapping{
+	{ErrInvalidAuthHeader, http.StatusUnauthorized, CodeInvalidAuthHeader},
+	{ErrCannotParseToken, http.StatusUnauthorized, CodeInvalidToken},
+	{ErrIncorrectMethod, http.StatusBadRequest, CodeInvalidMethod},
+	{ErrNoRights, http.StatusForbidden, CodeNoRights},
+	{ErrInvalidRequestBody, http.StatusBadRequest, CodeInvalidRequestBody},
+
+	{service.ErrUserNotFound, http.StatusUnauthorized, CodeInvalidCredentials},
+	{service.ErrUserAlreadyExists, http.StatusConflict, CodeUserAlreadyExists},
+	{service.ErrUnknownUser, http.StatusNotFound, CodeUserNotFound},
+	{service.ErrUserAlreadyAdmin, http.StatusConflict, CodeUserAlreadyAdmin},
+	{service.ErrCannotParseToken, http.StatusUnauthorized, CodeInvalidToken},
+	{service.ErrInvalidRefreshToken, http.StatusUnauthorized, CodeInvalidRefresh},
+	{service.ErrRefreshTokenReused, http.StatusUnauthorized, CodeRefreshTokenReused},
+	{service.ErrActorNotFound, http.StatusNotFound, CodeActorNotFound},
+	{service.ErrFilmNotFound, http.StatusNotFound, CodeFilmNotFound},
+	{service.ErrActorNotInTrash, http.StatusNotFound, CodeActorNotInTrash},
+	{service.ErrFilmNotInTrash, http.StatusNotFound, CodeFilmNotInTrash},
+	{service.ErrRevisionNotFound, http.StatusNotFound, CodeRevisionNotFound},
+	{service.ErrGenreNotFound, http.StatusNotFound, CodeGenreNotFound},
+	{service.ErrGenreAlreadyExists, http.StatusConflict, CodeGenreAlreadyExists},
+	{service.ErrInvalidFilmFilter, http.StatusBadRequest, CodeInvalidFilmFilter},
+	{service.ErrInvalidFilmSearch, http.StatusBadRequest, CodeInvalidFilmSearch},
+	{service.ErrInvalidAuditFilter, http.StatusBadRequest, CodeInvalidAuditFilter},
+	{service.ErrEmptyName, http.StatusBadRequest, CodeEmptyName},
+	{service.ErrInvalidCursor, http.StatusBadRequest, CodeInvalidCursor},
+	{service.ErrInvalidPageLimit, http.StatusBadRequest, CodeInvalidPageLimit},
+
+	{repoerrs.ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{repoerrs.ErrAlreadyExists, http.StatusConflict, CodeAlreadyExists},
+	{repoerrs.ErrInvalidCursor, http.StatusBadRequest, CodeInvalidCursor},
+}
+
+// details replace the messages of errors that are not meant for clients as is.
+var details = map[error]string{
+	service.ErrUserNotFound: "invalid username or password",
+}
+
+// newProblem maps err to a problem. Unknown errors become internal errors
+// without details, so that internal messages do not leak to clients.
+func newProblem(err error) *problem {
+	var validationErr *entity.ValidationError
+	if errors.As(err, &validationErr) {
+		p := newProblemWithCode(http.StatusBadRequest, CodeValidationFailed, "request has invalid fields")
+		p.Errors = validationErr.Fields
+		return p
+	}
+
+	var unknownActorsErr *service.UnknownActorsError
+	if errors.As(err, &unknownActorsErr) {
+		return newProblemWithCode(http.StatusUnprocessableEntity, CodeUnknownActors, err.Error())
+	}
+
+	var ambiguousActorsErr *service.AmbiguousActorsError
+	if errors.As(err, &ambiguousActorsErr) {
+		return newProblemWithCode(http.StatusUnprocessableEntity, CodeAmbiguousActors, err.Error())
+	}
+
+	var unknownGenresErr *service.UnknownGenresError
+	if errors.As(err, &unknownGenresErr) {
+		return newProblemWithCode(http.StatusUnprocessableEntity, CodeUnknownGenres, err.Error())
+	}
+
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			detail, ok := details[m.err]
+			if !ok {
+				detail = err.Error()
+			}
+			return newProblemWithCode(m.status, m.code, detail)
+		}
+	}
+
+	return newProblemWithCode(http.StatusInternalServerError, CodeInternal, "")
+}
+
+func newProblemWithCode(status int, code, detail string) *problem {
+	return &problem{
+		Type:   problemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// writeError writes err as a problem response.
+func writeError(w http.ResponseWriter, err error) {
+	writeProblemResponse(w, newProblem(err))
+}
+
+// writeProblem writes a problem response for an error found by a handler itself,
+// such as an unparsable parameter.
+func writeProblem(w http.ResponseWriter, status int, code, detail string) {
+	writeProblemResponse(w, newProblemWithCode(status, code, detail))
+}
+
+func writeProblemResponse(w http.ResponseWriter, p *problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
@@ -1,10 +1,11 @@
 package v1
 
 import (
-	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"vk-film-library/internal/entity"
 	"vk-film-library/internal/service"
 	"vk-film-library/pkg/logger"
@@ -21,11 +22,22 @@ func newFilmRoutes(mux *http.ServeMux, filmService service.Film, middleware *Aut
 		log:         log,
 	}
 
-	mux.HandleFunc("/api/v1/films/create", middleware.RequireAuth(ar.createFilm))
-	mux.HandleFunc("/api/v1/films/sorted", middleware.RequireAuth(ar.getSortFilms))
-	mux.HandleFunc("/api/v1/films/name", middleware.RequireAuth(ar.getFilmsByName))
-	mux.HandleFunc("/api/v1/films/actor", middleware.RequireAuth(ar.getFilmsByActor))
-	mux.HandleFunc("/api/v1/films/delete/{id}", middleware.RequireAuth(ar.deleteFilm))
+	mux.HandleFunc("/api/v1/films", middleware.RequirePermission(entity.PermFilmsRead, ar.getFilms))
+	mux.HandleFunc("/api/v1/films/create", middleware.RequirePermission(entity.PermFilmsWrite, ar.createFilm))
+	mux.HandleFunc("/api/v1/films/search", middleware.RequirePermission(entity.PermFilmsRead, ar.searchFilms))
+	mux.HandleFunc("/api/v1/films/find", middleware.RequirePermission(entity.PermFilmsRead, ar.findFilms))
+	mux.HandleFunc("/api/v1/films/{id}", middleware.RequirePermission(entity.PermFilmsRead, ar.getFilmByID))
+	mux.HandleFunc("/api/v1/films/sorted", middleware.RequirePermission(entity.PermFilmsRead, ar.getSortFilms))
+	mux.HandleFunc("/api/v1/films/name", middleware.RequirePermission(entity.PermFilmsRead, ar.getFilmsByName))
+	mux.HandleFunc("/api/v1/films/actor", middleware.RequirePermission(entity.PermFilmsRead, ar.getFilmsByActor))
+	mux.HandleFunc("/api/v1/films/edit/{id}", middleware.RequirePermission(entity.PermFilmsWrite, ar.editFilm))
+	mux.HandleFunc("/api/v1/films/delete/{id}", middleware.RequirePermission(entity.PermFilmsDelete, ar.deleteFilm))
+	mux.HandleFunc("/api/v1/films/trash", middleware.RequirePermission(entity.PermFilmsDelete, ar.getDeletedFilms))
+	mux.HandleFunc("/api/v1/films/restore/{id}", middleware.RequirePermission(entity.PermFilmsDelete, ar.restoreFilm))
+	mux.HandleFunc("/api/v1/films/purge/{id}", middleware.RequirePermission(entity.PermFilmsDelete, ar.purgeFilm))
+	mux.HandleFunc("/api/v1/films/revisions/{id}", middleware.RequirePermission(entity.PermRevisionsRead, ar.getFilmRevisions))
+	mux.HandleFunc("/api/v1/films/revisions/{id}/diff", middleware.RequirePermission(entity.PermRevisionsRead, ar.diffFilmRevisions))
+	mux.HandleFunc("/api/v1/films/revert/{id}", middleware.RequirePermission(entity.PermFilmsWrite, ar.revertFilm))
 }
 
 // @Summary Create film
@@ -35,34 +47,29 @@ func newFilmRoutes(mux *http.ServeMux, filmService service.Film, middleware *Aut
 // @Accept json
 // @Produce json
 // @Success 201 {object} v1.filmRoutes.createFilm.response
-// @Failure 400 {string} error
-// @Failure 500 {string} error
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 422 {object} v1.problem
+// @Failure 500 {object} v1.problem
 // @Security JWT
 // @Router /api/v1/films/create [post]
 func (fr *filmRoutes) createFilm(w http.ResponseWriter, req *http.Request) {
 	if req.Method != "POST" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
-		return
-	}
-
-	role := req.Header.Get(userRoleHeader)
-	if role != "admin" {
-		fr.log.Error("filmRoutes CreateFilm: user does not have the necessary rights")
-		http.Error(w, "you do not have the necessary rights", http.StatusBadRequest)
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
 	var input entity.FilmCreateInput
 	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
 		fr.log.Errorf("filmRoutes CreateFilm: invalid request body %v", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeError(w, ErrInvalidRequestBody)
 		return
 	}
 
-	id, err := fr.filmService.CreateFilm(context.Background(), &input)
+	id, err := fr.filmService.CreateFilm(req.Context(), &input)
 	if err != nil {
 		fr.log.Errorf("filmRoutes CreateFilm: filmService.CreateFilm %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -70,65 +77,378 @@ func (fr *filmRoutes) createFilm(w http.ResponseWriter, req *http.Request) {
 		Id int `json:"id"`
 	}
 
-	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	jsonResp, err := json.Marshal(response{Id: id})
 	if err != nil {
 		fr.log.Errorf("filmRoutes CreateFilm: cannot marshal response %v", err)
-		http.Error(w, "cannot marshal response", http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Get film
+// @Description Get film with its cast ordered by billing, crew and genres by id
+// @Tags films
+// @Param id path integer true "Film id"
+// @Produce json
+// @Success 200 {object} v1.filmRoutes.getFilmByID.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/films/{id} [get]
+func (fr *filmRoutes) getFilmByID(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilmByID: cannot get film id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get film id")
+		return
+	}
+
+	film, err := fr.filmService.GetFilmByID(req.Context(), id)
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilmByID: filmService.GetFilmByID %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Film *entity.Film `json:"film"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Film: film})
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilmByID: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Get films
+// @Description Get films matching all passed filters. The sort query parameter is a comma separated list
+// @Description of rating, name and created_at, a field prefixed with "-" is sorted in descending order.
+// @Description The response also holds the number of matching films of each genre, counted without the genres filter.
+// @Tags films
+// @Param name query string false "part of film name"
+// @Param actor query string false "part of actor name"
+// @Param actor_id query integer false "actor id"
+// @Param person_id query integer false "id of a person who worked on the film"
+// @Param role query string false "role of the person: actor, director, writer, producer or composer, any by default"
+// @Param genres query string false "comma separated genre ids"
+// @Param genres_match query string false "any (default) or all of the genres"
+// @Param min_rating query integer false "minimal rating"
+// @Param max_rating query integer false "maximal rating"
+// @Param released_from query string false "first release date, YYYY-MM-DD"
+// @Param released_to query string false "last release date, YYYY-MM-DD"
+// @Param sort query string false "sort fields, e.g. -rating,-created_at"
+// @Param limit query integer false "page size, 20 by default, 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Produce json
+// @Success 200 {object} v1.filmRoutes.getFilms.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/films [get]
+func (fr *filmRoutes) getFilms(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	filter, err := getFilmFilter(req)
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilms: invalid filter %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	page, err := getPage(req)
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilms: invalid page %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidPageLimit, "invalid page limit")
+		return
+	}
+
+	films, nextCursor, err := fr.filmService.GetFilms(req.Context(), filter, page)
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilms: filmService.GetFilms %v", err)
+		writeError(w, err)
+		return
+	}
+
+	genres, err := fr.filmService.CountFilmGenres(req.Context(), filter)
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilms: filmService.CountFilmGenres %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Films      []*entity.Film       `json:"films"`
+		Genres     []*entity.GenreCount `json:"genres"`
+		NextCursor string               `json:"next_cursor,omitempty"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Films: films, Genres: genres, NextCursor: nextCursor})
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilms: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// getFilmFilter reads the film filter from the query parameters.
+func getFilmFilter(req *http.Request) (*entity.FilmFilter, error) {
+	query := req.URL.Query()
+	filter := &entity.FilmFilter{
+		Name:      query.Get("name"),
+		ActorName: query.Get("actor"),
+	}
+
+	if actorId := query.Get("actor_id"); actorId != "" {
+		id, err := strconv.Atoi(actorId)
+		if err != nil {
+			return nil, fmt.Errorf("invalid actor_id")
+		}
+		filter.ActorId = id
+	}
+	if personId := query.Get("person_id"); personId != "" {
+		id, err := strconv.Atoi(personId)
+		if err != nil {
+			return nil, fmt.Errorf("invalid person_id")
+		}
+		filter.PersonId = id
+	}
+	filter.PersonRole = query.Get("role")
+	if genres := query.Get("genres"); genres != "" {
+		for _, genreId := range strings.Split(genres, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(genreId))
+			if err != nil {
+				return nil, fmt.Errorf("invalid genres")
+			}
+			filter.GenreIds = append(filter.GenreIds, id)
+		}
+	}
+	switch query.Get("genres_match") {
+	case "", "any":
+	case "all":
+		filter.AllGenres = true
+	default:
+		return nil, fmt.Errorf("invalid genres_match")
+	}
+	if minRating := query.Get("min_rating"); minRating != "" {
+		rating, err := strconv.Atoi(minRating)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min_rating")
+		}
+		filter.MinRating = &rating
+	}
+	if maxRating := query.Get("max_rating"); maxRating != "" {
+		rating, err := strconv.Atoi(maxRating)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_rating")
+		}
+		filter.MaxRating = &rating
+	}
+	if releasedFrom := query.Get("released_from"); releasedFrom != "" {
+		date, err := entity.ParseDate(releasedFrom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid released_from: %v", err)
+		}
+		filter.ReleasedFrom = date
+	}
+	if releasedTo := query.Get("released_to"); releasedTo != "" {
+		date, err := entity.ParseDate(releasedTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid released_to: %v", err)
+		}
+		filter.ReleasedTo = date
+	}
+	if sort := query.Get("sort"); sort != "" {
+		var err error
+		filter.Sort, err = entity.ParseFilmSort(sort)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return filter, nil
+}
+
+// @Summary Search films
+// @Description Full-text search over film names and descriptions, results are ordered by relevance.
+// @Description The language is detected from the query when it is not passed.
+// @Tags films
+// @Param q query string true "search query"
+// @Param lang query string false "search language, ru or en"
+// @Param limit query integer false "number of results, 20 by default, 100 at most"
+// @Produce json
+// @Success 200 {object} v1.filmRoutes.searchFilms.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/films/search [get]
+func (fr *filmRoutes) searchFilms(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	input := entity.FilmSearchInput{
+		Query: req.URL.Query().Get("q"),
+		Lang:  req.URL.Query().Get("lang"),
+	}
+	if limit := req.URL.Query().Get("limit"); limit != "" {
+		var err error
+		input.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			fr.log.Errorf("filmRoutes SearchFilms: invalid limit %v", err)
+			writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "invalid limit")
+			return
+		}
+	}
+
+	films, err := fr.filmService.SearchFilms(req.Context(), &input)
+	if err != nil {
+		fr.log.Errorf("filmRoutes SearchFilms: filmService.SearchFilms %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Films []*entity.FilmSearchResult `json:"films"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Films: films})
+	if err != nil {
+		fr.log.Errorf("filmRoutes SearchFilms: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Find films by name
+// @Description Find films whose name contains the passed one, ordered by similarity.
+// @Description When there are no such films, similar names are returned in did_you_mean.
+// @Tags films
+// @Param name query string true "film name"
+// @Produce json
+// @Success 200 {object} entity.NameSearchResult
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/films/find [get]
+func (fr *filmRoutes) findFilms(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	result, err := fr.filmService.FindFilms(req.Context(), req.URL.Query().Get("name"))
+	if err != nil {
+		fr.log.Errorf("filmRoutes FindFilms: filmService.FindFilms %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(result)
+	if err != nil {
+		fr.log.Errorf("filmRoutes FindFilms: cannot marshal response %v", err)
+		writeError(w, err)
 		return
 	}
 	w.Write(jsonResp)
 }
 
 // @Summary Get sort films
-// @Description Get sort films by field
+// @Description Get films sorted by one or more fields. The sort query parameter is a comma separated list
+// @Description of rating, name and created_at, a field prefixed with "-" is sorted in descending order.
+// @Description The body with a single sort field is still accepted when the query parameter is not set.
 // @Tags films
-// @Param input body entity.NamePart true "information about sort field"
+// @Param sort query string false "sort fields, e.g. -rating,-created_at"
+// @Param input body entity.NamePart false "information about sort field"
+// @Param limit query integer false "page size, 20 by default, 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Accept json
 // @Produce json
 // @Success 200 {object} v1.filmRoutes.getSortFilms.response
-// @Failure 400 {string} error
-// @Failure 500 {string} error
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
 // @Security JWT
+// @Router /api/v1/films/sorted [get]
 // @Router /api/v1/films/sorted [post]
 func (fr *filmRoutes) getSortFilms(w http.ResponseWriter, req *http.Request) {
-	if req.Method != "POST" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
+	if req.Method != "GET" && req.Method != "POST" {
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
-	role := req.Header.Get(userRoleHeader)
-	if role != "admin" && role != "user" {
-		fr.log.Error("filmRoutes getSortFilms: user does not have the necessary rights")
-		http.Error(w, "you do not have the necessary rights", http.StatusBadRequest)
+	sortParam := req.URL.Query().Get("sort")
+	if sortParam == "" {
+		var input entity.NamePart
+		if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+			fr.log.Errorf("filmRoutes getSortFilms: invalid request body %v", err)
+			writeError(w, ErrInvalidRequestBody)
+			return
+		}
+		sortParam = input.Name
+	}
+
+	sort, err := entity.ParseFilmSort(sortParam)
+	if err != nil {
+		fr.log.Errorf("filmRoutes getSortFilms: invalid sort %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
 		return
 	}
 
-	var input entity.NamePart
-	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
-		fr.log.Errorf("filmRoutes getSortFilms: invalid request body %v", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+	page, err := getPage(req)
+	if err != nil {
+		fr.log.Errorf("filmRoutes getSortFilms: invalid page %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidPageLimit, "invalid page limit")
 		return
 	}
 
-	films, err := fr.filmService.GetSortFilms(context.Background(), input.Name)
+	films, nextCursor, err := fr.filmService.GetSortFilms(req.Context(), sort, page)
 	if err != nil {
-		fr.log.Errorf("filmRoutes getSortFilms: filmService.GetFilmsByName %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		fr.log.Errorf("filmRoutes getSortFilms: filmService.GetSortFilms %v", err)
+		writeError(w, err)
 		return
 	}
 
 	type response struct {
-		Films []*entity.Film `json:"films"`
+		Films      []*entity.Film `json:"films"`
+		NextCursor string         `json:"next_cursor,omitempty"`
 	}
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
-	jsonResp, err := json.Marshal(response{Films: films})
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Films: films, NextCursor: nextCursor})
 	if err != nil {
 		fr.log.Errorf("filmRoutes getSortFilms: cannot marshal response %v", err)
-		http.Error(w, "cannot marshal response", http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 	w.Write(jsonResp)
@@ -138,147 +458,442 @@ func (fr *filmRoutes) getSortFilms(w http.ResponseWriter, req *http.Request) {
 // @Description Get films by part of name
 // @Tags films
 // @Param input body entity.NamePart true "information about film name"
+// @Param limit query integer false "page size, 20 by default, 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Accept json
 // @Produce json
 // @Success 200 {object} v1.filmRoutes.getFilmsByName.response
-// @Failure 400 {string} error
-// @Failure 500 {string} error
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
 // @Security JWT
 // @Router /api/v1/films/name [post]
 func (fr *filmRoutes) getFilmsByName(w http.ResponseWriter, req *http.Request) {
 	if req.Method != "POST" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
-		return
-	}
-
-	role := req.Header.Get(userRoleHeader)
-	if role != "admin" && role != "user" {
-		fr.log.Error("filmRoutes GetFilmsByName: user does not have the necessary rights")
-		http.Error(w, "you do not have the necessary rights", http.StatusBadRequest)
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
 	var input entity.NamePart
 	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
 		fr.log.Errorf("filmRoutes GetFilmsByName: invalid request body %v", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeError(w, ErrInvalidRequestBody)
 		return
 	}
 
-	films, err := fr.filmService.GetFilmsByName(context.Background(), input.Name)
+	page, err := getPage(req)
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilmsByName: invalid page %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidPageLimit, "invalid page limit")
+		return
+	}
+
+	films, nextCursor, err := fr.filmService.GetFilmsByName(req.Context(), input.Name, page)
 	if err != nil {
 		fr.log.Errorf("filmRoutes GetFilmsByName: filmService.GetFilmsByName %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	type response struct {
-		Films []*entity.Film `json:"films"`
+		Films      []*entity.Film `json:"films"`
+		NextCursor string         `json:"next_cursor,omitempty"`
 	}
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
-	jsonResp, err := json.Marshal(response{Films: films})
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Films: films, NextCursor: nextCursor})
 	if err != nil {
 		fr.log.Errorf("filmRoutes GetFilmsByName: cannot marshal response %v", err)
-		http.Error(w, "cannot marshal response", http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 	w.Write(jsonResp)
 }
 
 // @Summary Get films by actor name
-// @Description Get films by part of actor name
+// @Description Get films by part of actor name, similar actor names are suggested in did_you_mean if no actor name contains it
 // @Tags films
 // @Param input body entity.NamePart true "information about actor name"
+// @Param limit query integer false "page size, 20 by default, 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Accept json
 // @Produce json
 // @Success 200 {object} v1.filmRoutes.getFilmsByActor.response
-// @Failure 400 {string} error
-// @Failure 500 {string} error
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
 // @Security JWT
 // @Router /api/v1/films/actor [post]
 func (fr *filmRoutes) getFilmsByActor(w http.ResponseWriter, req *http.Request) {
 	if req.Method != "POST" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
-		return
-	}
-
-	role := req.Header.Get(userRoleHeader)
-	if role != "admin" && role != "user" {
-		fr.log.Error("filmRoutes GetFilmsByActor: user does not have the necessary rights")
-		http.Error(w, "you do not have the necessary rights", http.StatusBadRequest)
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
 	var input entity.NamePart
 	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
 		fr.log.Errorf("filmRoutes GetFilmsByActor: invalid request body %v", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeError(w, ErrInvalidRequestBody)
 		return
 	}
 
-	films, err := fr.filmService.GetFilmsByActor(context.Background(), input.Name)
+	page, err := getPage(req)
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilmsByActor: invalid page %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidPageLimit, "invalid page limit")
+		return
+	}
+
+	result, err := fr.filmService.GetFilmsByActor(req.Context(), input.Name, page)
 	if err != nil {
 		fr.log.Errorf("filmRoutes GetFilmsByActor: filmService.GetFilmsByActor %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	type response struct {
-		Films []*entity.Film `json:"films"`
+		Films      []*entity.Film      `json:"films"`
+		NextCursor string              `json:"next_cursor,omitempty"`
+		DidYouMean []*entity.NameMatch `json:"did_you_mean,omitempty"`
 	}
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
-	jsonResp, err := json.Marshal(response{Films: films})
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Films: result.Films, NextCursor: result.NextCursor, DidYouMean: result.DidYouMean})
 	if err != nil {
 		fr.log.Errorf("filmRoutes GetFilmsByActor: cannot marshal response %v", err)
-		http.Error(w, "cannot marshal response", http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 	w.Write(jsonResp)
 }
 
+// @Summary Edit film
+// @Description Partially update film, only the passed fields are changed
+// @Tags films
+// @Param id path integer true "Film id"
+// @Param input body entity.FilmEditInput true "changed fields of film"
+// @Accept json
+// @Success 200
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 422 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/films/edit/{id} [patch]
+func (fr *filmRoutes) editFilm(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "PATCH" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		fr.log.Errorf("filmRoutes EditFilm: cannot get film id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get film id")
+		return
+	}
+
+	var input entity.FilmEditInput
+	if err = json.NewDecoder(req.Body).Decode(&input); err != nil {
+		fr.log.Errorf("filmRoutes EditFilm: invalid request body %v", err)
+		writeError(w, ErrInvalidRequestBody)
+		return
+	}
+
+	err = fr.filmService.EditFilm(req.Context(), id, &input)
+	if err != nil {
+		fr.log.Errorf("filmRoutes EditFilm: filmService.EditFilm %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 // @Summary Delete film
-// @Description Delete film
+// @Description Move film to the trash, it can be restored or purged later
 // @Tags films
 // @Param id path integer true "Film id"
 // @Success 200
-// @Failure 400 {string} error
-// @Failure 404 {string} error
-// @Failure 500 {string} error
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
 // @Security JWT
 // @Router /api/v1/films/delete/{id} [delete]
 func (fr *filmRoutes) deleteFilm(w http.ResponseWriter, req *http.Request) {
 	if req.Method != "DELETE" {
-		http.Error(w, "incorrect http method", http.StatusBadRequest)
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		fr.log.Errorf("filmRoutes DeleteFilm: cannot get film id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get film id")
+		return
+	}
+
+	err = fr.filmService.DeleteFilm(req.Context(), id)
+	if err != nil {
+		fr.log.Errorf("filmRoutes DeleteFilm: filmService.DeleteFilm %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Get deleted films
+// @Description Get films in the trash, recently deleted first
+// @Tags films
+// @Produce json
+// @Success 200 {object} v1.filmRoutes.getDeletedFilms.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/films/trash [get]
+func (fr *filmRoutes) getDeletedFilms(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	films, err := fr.filmService.GetDeletedFilms(req.Context())
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetDeletedFilms: filmService.GetDeletedFilms %v", err)
+		writeError(w, err)
 		return
 	}
 
-	role := req.Header.Get(userRoleHeader)
-	if role != "admin" {
-		fr.log.Error("filmRoutes DeleteFilm: user does not have the necessary rights")
-		http.Error(w, "you do not have the necessary rights", http.StatusBadRequest)
+	type response struct {
+		Films []*entity.TrashItem `json:"films"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Films: films})
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetDeletedFilms: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Restore film
+// @Description Restore film from the trash together with its cast, crew and genres
+// @Tags films
+// @Param id path integer true "Film id"
+// @Success 200
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/films/restore/{id} [post]
+func (fr *filmRoutes) restoreFilm(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		writeError(w, ErrIncorrectMethod)
 		return
 	}
 
 	id, err := strconv.Atoi(req.PathValue("id"))
 	if err != nil {
-		fr.log.Errorf("filmRoutes DeleteFilm: cannot get film id %v", err)
-		http.Error(w, "cannot get film id", http.StatusBadRequest)
+		fr.log.Errorf("filmRoutes RestoreFilm: cannot get film id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get film id")
 		return
 	}
-	fr.log.Println(id)
 
-	err = fr.filmService.DeleteFilm(context.Background(), id)
+	err = fr.filmService.RestoreFilm(req.Context(), id)
 	if err != nil {
-		fr.log.Errorf("filmRoutes DeleteFilm: filmService.DeleteFilm %v", err)
-		if err == service.ErrFilmNotFound {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		fr.log.Errorf("filmRoutes RestoreFilm: filmService.RestoreFilm %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Purge film
+// @Description Permanently delete film from the trash
+// @Tags films
+// @Param id path integer true "Film id"
+// @Success 200
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/films/purge/{id} [delete]
+func (fr *filmRoutes) purgeFilm(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "DELETE" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		fr.log.Errorf("filmRoutes PurgeFilm: cannot get film id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get film id")
+		return
+	}
+
+	err = fr.filmService.PurgeFilm(req.Context(), id)
+	if err != nil {
+		fr.log.Errorf("filmRoutes PurgeFilm: filmService.PurgeFilm %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Get film revisions
+// @Description Get revisions of film without their data, most recent first
+// @Tags films
+// @Param id path integer true "Film id"
+// @Produce json
+// @Success 200 {object} v1.filmRoutes.getFilmRevisions.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/films/revisions/{id} [get]
+func (fr *filmRoutes) getFilmRevisions(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilmRevisions: cannot get film id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get film id")
+		return
+	}
+
+	revisions, err := fr.filmService.GetFilmRevisions(req.Context(), id)
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilmRevisions: filmService.GetFilmRevisions %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Revisions []*entity.Revision `json:"revisions"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Revisions: revisions})
+	if err != nil {
+		fr.log.Errorf("filmRoutes GetFilmRevisions: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Diff film revisions
+// @Description Get fields of film changed from one revision to another
+// @Tags films
+// @Param id path integer true "Film id"
+// @Param from query integer true "number of the older revision"
+// @Param to query integer true "number of the newer revision"
+// @Produce json
+// @Success 200 {object} entity.RevisionDiff
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/films/revisions/{id}/diff [get]
+func (fr *filmRoutes) diffFilmRevisions(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		fr.log.Errorf("filmRoutes DiffFilmRevisions: cannot get film id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get film id")
+		return
+	}
+
+	from, err := getRevisionNumber(req, "from")
+	if err != nil {
+		fr.log.Errorf("filmRoutes DiffFilmRevisions: %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+	to, err := getRevisionNumber(req, "to")
+	if err != nil {
+		fr.log.Errorf("filmRoutes DiffFilmRevisions: %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	diff, err := fr.filmService.DiffFilmRevisions(req.Context(), id, from, to)
+	if err != nil {
+		fr.log.Errorf("filmRoutes DiffFilmRevisions: filmService.DiffFilmRevisions %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(diff)
+	if err != nil {
+		fr.log.Errorf("filmRoutes DiffFilmRevisions: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Revert film
+// @Description Bring film, its cast, crew and genres back to the state of a revision, recorded as a new revision
+// @Tags films
+// @Param id path integer true "Film id"
+// @Param revision query integer true "number of the revision"
+// @Success 200
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 422 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/films/revert/{id} [post]
+func (fr *filmRoutes) revertFilm(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		fr.log.Errorf("filmRoutes RevertFilm: cannot get film id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get film id")
+		return
+	}
+
+	number, err := getRevisionNumber(req, "revision")
+	if err != nil {
+		fr.log.Errorf("filmRoutes RevertFilm: %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	err = fr.filmService.RevertFilm(req.Context(), id, number)
+	if err != nil {
+		fr.log.Errorf("filmRoutes RevertFilm: filmService.RevertFilm %v", err)
+		writeError(w, err)
 		return
 	}
 
@@ -0,0 +1,239 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/service"
+	"vk-film-library/pkg/logger"
+)
+
+type genreRoutes struct {
+	genreService service.Genre
+	log          *logger.Logger
+}
+
+func newGenreRoutes(mux *http.ServeMux, genreService service.Genre, middleware *AuthMiddleware, log *logger.Logger) {
+	gr := &genreRoutes{
+		genreService: genreService,
+		log:          log,
+	}
+
+	mux.HandleFunc("/api/v1/genres/create", middleware.RequirePermission(entity.PermGenresWrite, gr.createGenre))
+	mux.HandleFunc("/api/v1/genres/{id}", middleware.RequirePermission(entity.PermGenresRead, gr.getGenreByID))
+	mux.HandleFunc("/api/v1/genres", middleware.RequirePermission(entity.PermGenresRead, gr.getAllGenres))
+	mux.HandleFunc("/api/v1/genres/edit/{id}", middleware.RequirePermission(entity.PermGenresWrite, gr.editGenre))
+	mux.HandleFunc("/api/v1/genres/delete/{id}", middleware.RequirePermission(entity.PermGenresDelete, gr.deleteGenre))
+}
+
+// @Summary Create genre
+// @Description Create genre
+// @Tags genres
+// @Param input body entity.GenreInput true "information about stored genre"
+// @Accept json
+// @Produce json
+// @Success 201 {object} v1.genreRoutes.createGenre.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 409 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/genres/create [post]
+func (gr *genreRoutes) createGenre(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	var input entity.GenreInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		gr.log.Errorf("genreRoutes CreateGenre: invalid request body %v", err)
+		writeError(w, ErrInvalidRequestBody)
+		return
+	}
+
+	id, err := gr.genreService.CreateGenre(req.Context(), &input)
+	if err != nil {
+		gr.log.Errorf("genreRoutes CreateGenre: genreService.CreateGenre %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Id int `json:"id"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	jsonResp, err := json.Marshal(response{Id: id})
+	if err != nil {
+		gr.log.Errorf("genreRoutes CreateGenre: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Get genre
+// @Description Get genre by id
+// @Tags genres
+// @Param id path integer true "Genre id"
+// @Produce json
+// @Success 200 {object} v1.genreRoutes.getGenreByID.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/genres/{id} [get]
+func (gr *genreRoutes) getGenreByID(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		gr.log.Errorf("genreRoutes GetGenreByID: cannot get genre id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get genre id")
+		return
+	}
+
+	genre, err := gr.genreService.GetGenreByID(req.Context(), id)
+	if err != nil {
+		gr.log.Errorf("genreRoutes GetGenreByID: genreService.GetGenreByID %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Genre *entity.Genre `json:"genre"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Genre: genre})
+	if err != nil {
+		gr.log.Errorf("genreRoutes GetGenreByID: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Get all genres
+// @Description Get all genres ordered by name
+// @Tags genres
+// @Produce json
+// @Success 200 {object} v1.genreRoutes.getAllGenres.response
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/genres [get]
+func (gr *genreRoutes) getAllGenres(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	genres, err := gr.genreService.GetAllGenres(req.Context())
+	if err != nil {
+		gr.log.Errorf("genreRoutes GetAllGenres: genreService.GetAllGenres %v", err)
+		writeError(w, err)
+		return
+	}
+
+	type response struct {
+		Genres []*entity.Genre `json:"genres"`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, err := json.Marshal(response{Genres: genres})
+	if err != nil {
+		gr.log.Errorf("genreRoutes GetAllGenres: cannot marshal response %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Write(jsonResp)
+}
+
+// @Summary Edit genre
+// @Description Rename genre
+// @Tags genres
+// @Param id path integer true "Genre id"
+// @Param input body entity.GenreInput true "new information about genre"
+// @Accept json
+// @Success 200
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 409 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/genres/edit/{id} [put]
+func (gr *genreRoutes) editGenre(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "PUT" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		gr.log.Errorf("genreRoutes EditGenre: cannot get genre id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get genre id")
+		return
+	}
+
+	var input entity.GenreInput
+	if err = json.NewDecoder(req.Body).Decode(&input); err != nil {
+		gr.log.Errorf("genreRoutes EditGenre: invalid request body %v", err)
+		writeError(w, ErrInvalidRequestBody)
+		return
+	}
+
+	err = gr.genreService.EditGenre(req.Context(), id, &input)
+	if err != nil {
+		gr.log.Errorf("genreRoutes EditGenre: genreService.EditGenre %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Delete genre
+// @Description Delete genre, films of the genre are kept
+// @Tags genres
+// @Param id path integer true "Genre id"
+// @Success 200
+// @Failure 400 {object} v1.problem
+// @Failure 403 {object} v1.problem
+// @Failure 404 {object} v1.problem
+// @Failure 500 {object} v1.problem
+// @Security JWT
+// @Router /api/v1/genres/delete/{id} [delete]
+func (gr *genreRoutes) deleteGenre(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "DELETE" {
+		writeError(w, ErrIncorrectMethod)
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		gr.log.Errorf("genreRoutes DeleteGenre: cannot get genre id %v", err)
+		writeProblem(w, http.StatusBadRequest, CodeInvalidParameter, "cannot get genre id")
+		return
+	}
+
+	err = gr.genreService.DeleteGenre(req.Context(), id)
+	if err != nil {
+		gr.log.Errorf("genreRoutes DeleteGenre: genreService.DeleteGenre %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
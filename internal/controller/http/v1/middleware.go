@@ -1,39 +1,62 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"vk-film-library/internal/service"
 	"vk-film-library/pkg/logger"
 )
 
-const (
-	userRoleHeader = "role"
-)
-
 type AuthMiddleware struct {
 	authService service.Auth
 	log         *logger.Logger
 }
 
+// RequireAuth authenticates the request by its access token and passes the
+// principal to next in the request context. Handlers must take the user from
+// the context only, never from request headers.
 func (m *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		token, ok := getToken(req)
 		if !ok {
 			m.log.Errorf("AuthMiddleware RequireAuth: getToken %v", ErrInvalidAuthHeader)
-			http.Error(w, ErrInvalidAuthHeader.Error(), http.StatusUnauthorized)
+			writeError(w, ErrInvalidAuthHeader)
 			return
 		}
 
-		userRole, err := m.authService.ParseToken(token)
+		principal, err := m.authService.ParseToken(req.Context(), token)
 		if err != nil {
 			m.log.Errorf("AuthMiddleware RequireAuth: authService.ParseToken %v", err)
-			http.Error(w, ErrCannotParseToken.Error(), http.StatusUnauthorized)
+			if errors.Is(err, service.ErrCannotParseToken) {
+				err = ErrCannotParseToken
+			}
+			writeError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, req.WithContext(service.WithPrincipal(req.Context(), principal)))
+	})
+}
+
+// RequirePermission authenticates the request like RequireAuth and lets it
+// through only if the role of the user has the permission.
+func (m *AuthMiddleware) RequirePermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return m.RequireAuth(func(w http.ResponseWriter, req *http.Request) {
+		principal, _ := service.PrincipalFromContext(req.Context())
+
+		ok, err := m.authService.HasPermission(req.Context(), principal.Role, permission)
+		if err != nil {
+			m.log.Errorf("AuthMiddleware RequirePermission: authService.HasPermission %v", err)
+			writeError(w, err)
+			return
+		}
+		if !ok {
+			m.log.Errorf("AuthMiddleware RequirePermission: role %s has no permission %s", principal.Role, permission)
+			writeError(w, ErrNoRights)
 			return
 		}
 
-		req.Header.Add(userRoleHeader, userRole)
-		w.Header().Add(userRoleHeader, userRole)
 		next.ServeHTTP(w, req)
 	})
 }
@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"vk-film-library/internal/entity"
+)
+
+// getPage reads the limit and cursor query parameters of a paginated listing.
+func getPage(req *http.Request) (*entity.PageInput, error) {
+	page := &entity.PageInput{
+		Cursor: req.URL.Query().Get("cursor"),
+	}
+
+	if limit := req.URL.Query().Get("limit"); limit != "" {
+		var err error
+		page.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
@@ -0,0 +1,17 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// getRevisionNumber reads a revision number from the query parameter.
+func getRevisionNumber(req *http.Request, name string) (int, error) {
+	number, err := strconv.Atoi(req.URL.Query().Get(name))
+	if err != nil || number < 1 {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+
+	return number, nil
+}
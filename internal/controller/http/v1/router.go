@@ -10,9 +10,12 @@ import (
 func NewRouter(mux *http.ServeMux, services *service.Services, log *logger.Logger) {
 	mux.HandleFunc("/swagger/", httpSwagger.Handler())
 
-	newAuthRoutes(mux, services.Auth, log)
 	authMiddleware := &AuthMiddleware{services.Auth, log}
+	newAuthRoutes(mux, services.Auth, authMiddleware, log)
+	newAdminRoutes(mux, services.Auth, authMiddleware, log)
 
 	newActorRoutes(mux, services.Actor, authMiddleware, log)
 	newFilmRoutes(mux, services.Film, authMiddleware, log)
+	newGenreRoutes(mux, services.Genre, authMiddleware, log)
+	newAuditRoutes(mux, services.Audit, authMiddleware, log)
 }
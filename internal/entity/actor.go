@@ -1,15 +1,150 @@
 package entity
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	GenderMale   = "male"
+	GenderFemale = "female"
+	GenderOther  = "other"
+)
+
+var genders = map[string]bool{
+	GenderMale:   true,
+	GenderFemale: true,
+	GenderOther:  true,
+}
+
+const maxActorNameLength = 100
+
+// minActorBirthday is early enough for the actors of the first films.
+var minActorBirthday = time.Date(1800, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 type Actor struct {
-	Id       int    `db:"id"`
-	Name     string `json:"name" db:"name"`
-	Gender   string `json:"gender" db:"gender"`
-	Birthday string `json:"birthday" db:"birthday"`
-	Films    []string
+	Id       int       `db:"id"`
+	Name     string    `json:"name" db:"name"`
+	Gender   string    `json:"gender" db:"gender"`
+	Birthday time.Time `json:"birthday" db:"birthday"`
+	Films    []*ActorFilm
+	Credits  []*PersonCredit `json:"credits"`
+}
+
+func (a Actor) MarshalJSON() ([]byte, error) {
+	type actor Actor
+	return json.Marshal(struct {
+		actor
+		Birthday Date `json:"birthday"`
+	}{
+		actor:    actor(a),
+		Birthday: Date(a.Birthday),
+	})
+}
+
+func (a *Actor) UnmarshalJSON(data []byte) error {
+	type actor Actor
+	aux := struct {
+		*actor
+		Birthday *Date `json:"birthday"`
+	}{
+		actor: (*actor)(a),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Birthday != nil {
+		a.Birthday = time.Time(*aux.Birthday)
+	}
+
+	return nil
+}
+
+// ActorFilm is a film of an actor's filmography with the actor's credit in it.
+type ActorFilm struct {
+	Id         int       `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	Rating     int       `json:"rating" db:"rating"`
+	Character  string    `json:"character" db:"character"`
+	Billing    int       `json:"billing" db:"billing"`
+	CreditType string    `json:"credit_type" db:"credit_type"`
+}
+
+func (f ActorFilm) MarshalJSON() ([]byte, error) {
+	type actorFilm ActorFilm
+	return json.Marshal(struct {
+		actorFilm
+		CreatedAt Date `json:"created_at"`
+	}{
+		actorFilm: actorFilm(f),
+		CreatedAt: Date(f.CreatedAt),
+	})
 }
 
 type ActorCreateInput struct {
 	Name     string `json:"name"`
-	Gender   string `json:"gender"`
-	Birthday string `json:"birthday"`
+	Gender   string `json:"gender" enums:"male,female,other"`
+	Birthday Date   `json:"birthday" swaggertype:"string" example:"1990-01-01"`
+}
+
+// ActorEditInput changes only the fields that are set.
+type ActorEditInput struct {
+	Id       int     `json:"id"`
+	Name     *string `json:"name"`
+	Gender   *string `json:"gender" enums:"male,female,other"`
+	Birthday *Date   `json:"birthday" swaggertype:"string" example:"1990-01-01"`
+}
+
+func (form *ActorCreateInput) Validate() error {
+	var errs ValidationError
+	validateActorName(&errs, form.Name)
+	validateActorGender(&errs, form.Gender)
+	validateActorBirthday(&errs, time.Time(form.Birthday))
+
+	return errs.err()
+}
+
+// Validate checks only the fields that are set, using the same rules as ActorCreateInput.
+func (form *ActorEditInput) Validate() error {
+	var errs ValidationError
+	if form.Id <= 0 {
+		errs.add("id", "must be positive")
+	}
+	if form.Name != nil {
+		validateActorName(&errs, *form.Name)
+	}
+	if form.Gender != nil {
+		validateActorGender(&errs, *form.Gender)
+	}
+	if form.Birthday != nil {
+		validateActorBirthday(&errs, time.Time(*form.Birthday))
+	}
+
+	return errs.err()
+}
+
+func validateActorName(errs *ValidationError, name string) {
+	if strings.TrimSpace(name) == "" || utf8.RuneCountInString(name) > maxActorNameLength {
+		errs.add("name", fmt.Sprintf("must be from 1 to %d characters", maxActorNameLength))
+	}
+}
+
+func validateActorGender(errs *ValidationError, gender string) {
+	if !genders[gender] {
+		errs.add("gender", fmt.Sprintf("must be one of %s, %s, %s", GenderMale, GenderFemale, GenderOther))
+	}
+}
+
+func validateActorBirthday(errs *ValidationError, birthday time.Time) {
+	if birthday.IsZero() {
+		errs.add("birthday", "is required")
+		return
+	}
+	if birthday.Before(minActorBirthday) || birthday.After(time.Now()) {
+		errs.add("birthday", fmt.Sprintf("must be between %s and today", minActorBirthday.Format(DateLayout)))
+	}
 }
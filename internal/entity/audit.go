@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditCreate  = "create"
+	AuditEdit    = "edit"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+	AuditRevert  = "revert"
+	AuditPromote = "promote"
+)
+
+// Types of the audited entities. Only the creation and promotion of admins are
+// audited for users.
+const (
+	EntityFilm  = "film"
+	EntityActor = "actor"
+	EntityUser  = "user"
+)
+
+var entityTypes = map[string]bool{
+	EntityFilm:  true,
+	EntityActor: true,
+	EntityUser:  true,
+}
+
+// AuditEntry is a change of an entity made by a user. Before and After are JSON
+// snapshots of the entity, null when the entity did not exist or was in the trash.
+type AuditEntry struct {
+	Id         int             `json:"id" db:"id"`
+	UserId     int             `json:"user_id" db:"user_id"`
+	Username   string          `json:"username" db:"username"`
+	Action     string          `json:"action" db:"action"`
+	EntityType string          `json:"entity_type" db:"entity_type"`
+	EntityId   int             `json:"entity_id" db:"entity_id"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	Before     json.RawMessage `json:"before" db:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after" db:"after" swaggertype:"object"`
+}
+
+// AuditFilter selects audit entries, zero fields are not applied.
+// From and To bound the time of the change inclusively.
+type AuditFilter struct {
+	EntityType string
+	EntityId   int
+	UserId     int
+	From       time.Time
+	To         time.Time
+}
+
+func (filter *AuditFilter) Validate() error {
+	if filter.EntityType != "" && !entityTypes[filter.EntityType] {
+		return fmt.Errorf("entity type is invalid")
+	}
+	if filter.EntityId != 0 && filter.EntityType == "" {
+		return fmt.Errorf("entity id requires entity type")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return fmt.Errorf("time range is invalid")
+	}
+
+	return nil
+}
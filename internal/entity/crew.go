@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Roles of a person in a film. Actors are the cast of a film, other roles are its crew.
+// A person of any role is stored as an Actor.
+const (
+	RoleActor    = "actor"
+	RoleDirector = "director"
+	RoleWriter   = "writer"
+	RoleProducer = "producer"
+	RoleComposer = "composer"
+)
+
+var crewRoles = map[string]bool{
+	RoleDirector: true,
+	RoleWriter:   true,
+	RoleProducer: true,
+	RoleComposer: true,
+}
+
+// IsCrewRole reports whether role is a film role other than acting.
+func IsCrewRole(role string) bool {
+	return crewRoles[role]
+}
+
+// FilmCrewMember is a person who worked on a film in a role other than acting.
+type FilmCrewMember struct {
+	Id   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	Role string `json:"role" db:"role"`
+}
+
+// CrewInput is a crew member of a film referenced by id or, if PersonId is
+// zero, by name.
+type CrewInput struct {
+	PersonId int    `json:"person_id"`
+	Name     string `json:"name"`
+	Role     string `json:"role" enums:"director,writer,producer,composer"`
+}
+
+func NewFilmCrew(input []CrewInput) []*FilmCrewMember {
+	crew := make([]*FilmCrewMember, 0, len(input))
+	for _, c := range input {
+		crew = append(crew, &FilmCrewMember{Id: c.PersonId, Name: c.Name, Role: c.Role})
+	}
+
+	return crew
+}
+
+// PersonCredit is a film a person worked on in a role other than acting.
+type PersonCredit struct {
+	Id        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	Rating    int       `json:"rating" db:"rating"`
+	Role      string    `json:"role" db:"role"`
+}
+
+func (c PersonCredit) MarshalJSON() ([]byte, error) {
+	type personCredit PersonCredit
+	return json.Marshal(struct {
+		personCredit
+		CreatedAt Date `json:"created_at"`
+	}{
+		personCredit: personCredit(c),
+		CreatedAt:    Date(c.CreatedAt),
+	})
+}
+
+func validateFilmCrew(errs *ValidationError, crew []CrewInput) {
+	for i, c := range crew {
+		field := fmt.Sprintf("crew[%d]", i)
+		if c.PersonId < 0 {
+			errs.add(field+".person_id", "must be positive")
+		}
+		if c.PersonId == 0 && c.Name == "" {
+			errs.add(field+".name", "is required without person_id")
+		}
+		if !IsCrewRole(c.Role) {
+			errs.add(field+".role", fmt.Sprintf("must be one of %s, %s, %s, %s",
+				RoleDirector, RoleWriter, RoleProducer, RoleComposer))
+		}
+	}
+}
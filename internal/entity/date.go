@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the JSON format of actor birthdays and film release dates.
+const DateLayout = time.DateOnly
+
+// Date is a calendar date that is serialized to JSON as "YYYY-MM-DD".
+type Date time.Time
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(d).Format(DateLayout))
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("date must be a string in format YYYY-MM-DD")
+	}
+
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return fmt.Errorf("date %q is not in format YYYY-MM-DD", s)
+	}
+	*d = Date(t)
+
+	return nil
+}
+
+// ParseDate parses a date in the JSON format.
+func ParseDate(s string) (time.Time, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date %q is not in format YYYY-MM-DD", s)
+	}
+
+	return t, nil
+}
@@ -1,24 +1,254 @@
 package entity
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+// minFilmReleaseDate is the year of the first films, a release date may also be
+// at most maxFilmReleaseYearsAhead years in the future for announced films.
+var minFilmReleaseDate = time.Date(1888, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+const maxFilmReleaseYearsAhead = 10
+
 type Film struct {
-	Id          int    `db:"id"`
-	Name        string `json:"name" db:"name"`
-	Description string `json:"description" db:"description"`
-	CreatedAt   string `json:"created_at" db:"created_at"`
-	Rating      int    `json:"rating" db:"rating"`
-	Actors      []string
+	Id          int       `db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	Rating      int       `json:"rating" db:"rating"`
+	Actors      []*FilmActor
+	Crew        []*FilmCrewMember `json:"crew"`
+	Genres      []*Genre          `json:"genres"`
+}
+
+func (f Film) MarshalJSON() ([]byte, error) {
+	type film Film
+	return json.Marshal(struct {
+		film
+		CreatedAt Date `json:"created_at"`
+	}{
+		film:      film(f),
+		CreatedAt: Date(f.CreatedAt),
+	})
+}
+
+// FilmActor is an actor of a film cast. Billing is the position of the actor
+// in the credits, the cast is ordered by it.
+type FilmActor struct {
+	Id         int    `json:"id" db:"id"`
+	Name       string `json:"name" db:"name"`
+	Character  string `json:"character" db:"character"`
+	Billing    int    `json:"billing" db:"billing"`
+	CreditType string `json:"credit_type" db:"credit_type"`
+}
+
+const (
+	CreditLead       = "lead"
+	CreditSupporting = "supporting"
+	CreditCameo      = "cameo"
+	CreditVoice      = "voice"
+)
+
+var creditTypes = map[string]bool{
+	CreditLead:       true,
+	CreditSupporting: true,
+	CreditCameo:      true,
+	CreditVoice:      true,
+}
+
+// CastInput is an actor of a film cast referenced by id or, if ActorId is
+// zero, by name. A plain actor name is accepted as well. Zero Billing means
+// the position in the list, empty CreditType means CreditSupporting.
+type CastInput struct {
+	ActorId    int    `json:"actor_id"`
+	Name       string `json:"name"`
+	Character  string `json:"character"`
+	Billing    int    `json:"billing"`
+	CreditType string `json:"credit_type" enums:"lead,supporting,cameo,voice"`
+}
+
+func (c *CastInput) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*c = CastInput{Name: name}
+		return nil
+	}
+
+	type castInput CastInput
+	return json.Unmarshal(data, (*castInput)(c))
+}
+
+// NewFilmCast makes the cast of a film from the input, filling the default
+// billing and credit type.
+func NewFilmCast(input []CastInput) []*FilmActor {
+	cast := make([]*FilmActor, 0, len(input))
+	for i, c := range input {
+		actor := &FilmActor{
+			Id:         c.ActorId,
+			Name:       c.Name,
+			Character:  c.Character,
+			Billing:    c.Billing,
+			CreditType: c.CreditType,
+		}
+		if actor.Billing == 0 {
+			actor.Billing = i + 1
+		}
+		if actor.CreditType == "" {
+			actor.CreditType = CreditSupporting
+		}
+
+		cast = append(cast, actor)
+	}
+
+	return cast
 }
 
 type FilmCreateInput struct {
-	Name        string   `json:"name" db:"name"`
-	Description string   `json:"description" db:"description"`
-	CreatedAt   string   `json:"created_at" db:"created_at"`
-	Rating      int      `json:"rating" db:"rating"`
-	Actors      []string `json:"actors"`
+	Name        string      `json:"name" db:"name"`
+	Description string      `json:"description" db:"description"`
+	CreatedAt   Date        `json:"created_at" db:"created_at" swaggertype:"string" example:"2010-01-01"`
+	Rating      int         `json:"rating" db:"rating"`
+	Actors      []CastInput `json:"actors"`
+	Crew        []CrewInput `json:"crew"`
+	Genres      []int       `json:"genres"`
+}
+
+type FilmEditInput struct {
+	Name        *string      `json:"name"`
+	Description *string      `json:"description"`
+	CreatedAt   *Date        `json:"created_at" swaggertype:"string" example:"2010-01-01"`
+	Rating      *int         `json:"rating"`
+	Actors      *[]CastInput `json:"actors"`
+	Crew        *[]CrewInput `json:"crew"`
+	Genres      *[]int       `json:"genres"`
+}
+
+// FilmFilter selects films for a listing, zero fields are not applied.
+// Films having any of GenreIds are selected, or all of them if AllGenres is set.
+// PersonId selects films the person worked on in PersonRole, or in any role if it is empty.
+type FilmFilter struct {
+	Name         string
+	ActorName    string
+	ActorId      int
+	PersonId     int
+	PersonRole   string
+	GenreIds     []int
+	AllGenres    bool
+	MinRating    *int
+	MaxRating    *int
+	ReleasedFrom time.Time
+	ReleasedTo   time.Time
+	Sort         []SortKey
+}
+
+func (filter *FilmFilter) Validate() error {
+	if filter.MinRating != nil && !isValidFilmRating(*filter.MinRating) {
+		return fmt.Errorf("film rating is invalid")
+	}
+	if filter.MaxRating != nil && !isValidFilmRating(*filter.MaxRating) {
+		return fmt.Errorf("film rating is invalid")
+	}
+	if filter.MinRating != nil && filter.MaxRating != nil && *filter.MinRating > *filter.MaxRating {
+		return fmt.Errorf("film rating range is invalid")
+	}
+	if !filter.ReleasedFrom.IsZero() && !filter.ReleasedTo.IsZero() && filter.ReleasedFrom.After(filter.ReleasedTo) {
+		return fmt.Errorf("film release date range is invalid")
+	}
+	if filter.PersonRole != "" {
+		if filter.PersonId == 0 {
+			return fmt.Errorf("person role requires person id")
+		}
+		if filter.PersonRole != RoleActor && !IsCrewRole(filter.PersonRole) {
+			return fmt.Errorf("person role is invalid")
+		}
+	}
+	for _, id := range filter.GenreIds {
+		if id < 1 {
+			return fmt.Errorf("genre id is invalid")
+		}
+	}
+
+	return nil
+}
+
+// FilmSearchResult is a film found by full-text search, headlines are
+// HTML-escaped fragments of the name and the description with the matched
+// words wrapped in <b></b>.
+type FilmSearchResult struct {
+	Film                *Film   `json:"film"`
+	Rank                float32 `json:"rank"`
+	NameHeadline        string  `json:"name_headline"`
+	DescriptionHeadline string  `json:"description_headline"`
+}
+
+type FilmSearchInput struct {
+	Query string
+	Lang  string
+	Limit int
+}
+
+var searchLanguages = map[string]bool{
+	"ru": true,
+	"en": true,
+}
+
+func (form *FilmSearchInput) Validate() error {
+	if len(form.Query) < 1 || len(form.Query) > 200 {
+		return fmt.Errorf("search query is invalid")
+	}
+	if !searchLanguages[form.Lang] {
+		return fmt.Errorf("search language is invalid")
+	}
+	if form.Limit < 1 || form.Limit > MaxPageLimit {
+		return fmt.Errorf("search limit is invalid")
+	}
+
+	return nil
+}
+
+// SortKey is one key of a listing order, Desc reverses its direction.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+var filmSortFields = map[string]bool{
+	"rating":     true,
+	"name":       true,
+	"created_at": true,
+}
+
+// ParseFilmSort parses a comma separated list of film sort fields, a field
+// prefixed with "-" is sorted in descending order, e.g. "-rating,-created_at".
+func ParseFilmSort(sort string) ([]SortKey, error) {
+	if sort == "" {
+		return nil, fmt.Errorf("sort is empty")
+	}
+
+	keys := make([]SortKey, 0)
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(sort, ",") {
+		key := SortKey{Field: strings.TrimSpace(field)}
+		if strings.HasPrefix(key.Field, "-") {
+			key.Field = key.Field[1:]
+			key.Desc = true
+		}
+		if !filmSortFields[key.Field] {
+			return nil, fmt.Errorf("invalid sort field %q", key.Field)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("duplicate sort field %q", key.Field)
+		}
+		seen[key.Field] = true
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
 }
 
 type NamePart struct {
@@ -26,15 +256,89 @@ type NamePart struct {
 }
 
 func (form *FilmCreateInput) Validate() error {
-	if len(form.Name) < 1 || len(form.Name) > 150 {
-		return fmt.Errorf("film name is invalid")
+	var errs ValidationError
+	validateFilmName(&errs, form.Name)
+	validateFilmDescription(&errs, form.Description)
+	validateFilmReleaseDate(&errs, time.Time(form.CreatedAt))
+	validateFilmRating(&errs, form.Rating)
+	validateFilmCast(&errs, form.Actors)
+	validateFilmCrew(&errs, form.Crew)
+
+	return errs.err()
+}
+
+// Validate checks only the fields that are set, using the same rules as FilmCreateInput.
+func (form *FilmEditInput) Validate() error {
+	var errs ValidationError
+	if form.Name != nil {
+		validateFilmName(&errs, *form.Name)
 	}
-	if len(form.Description) > 1000 {
-		return fmt.Errorf("film description is invalid")
+	if form.Description != nil {
+		validateFilmDescription(&errs, *form.Description)
 	}
-	if form.Rating < 0 || form.Rating > 10 {
-		return fmt.Errorf("film rating is invalid")
+	if form.CreatedAt != nil {
+		validateFilmReleaseDate(&errs, time.Time(*form.CreatedAt))
+	}
+	if form.Rating != nil {
+		validateFilmRating(&errs, *form.Rating)
+	}
+	if form.Actors != nil {
+		validateFilmCast(&errs, *form.Actors)
+	}
+	if form.Crew != nil {
+		validateFilmCrew(&errs, *form.Crew)
 	}
 
-	return nil
+	return errs.err()
+}
+
+func validateFilmName(errs *ValidationError, name string) {
+	if n := utf8.RuneCountInString(name); n < 1 || n > 150 {
+		errs.add("name", "must be from 1 to 150 characters")
+	}
+}
+
+func validateFilmDescription(errs *ValidationError, description string) {
+	if utf8.RuneCountInString(description) > 1000 {
+		errs.add("description", "must be at most 1000 characters")
+	}
+}
+
+func validateFilmReleaseDate(errs *ValidationError, date time.Time) {
+	if date.Before(minFilmReleaseDate) || date.After(time.Now().AddDate(maxFilmReleaseYearsAhead, 0, 0)) {
+		errs.add("created_at", fmt.Sprintf("must be between %s and %d years from today",
+			minFilmReleaseDate.Format(DateLayout), maxFilmReleaseYearsAhead))
+	}
+}
+
+func validateFilmRating(errs *ValidationError, rating int) {
+	if !isValidFilmRating(rating) {
+		errs.add("rating", "must be from 0 to 10")
+	}
+}
+
+func isValidFilmRating(rating int) bool {
+	return rating >= 0 && rating <= 10
+}
+
+func validateFilmCast(errs *ValidationError, cast []CastInput) {
+	for i, c := range cast {
+		field := fmt.Sprintf("actors[%d]", i)
+		if c.ActorId < 0 {
+			errs.add(field+".actor_id", "must be positive")
+		}
+		if c.ActorId == 0 && c.Name == "" {
+			errs.add(field+".name", "is required without actor_id")
+		}
+		if utf8.RuneCountInString(c.Character) > 150 {
+			errs.add(field+".character", "must be at most 150 characters")
+		}
+		if c.Billing < 0 {
+			errs.add(field+".billing", "must not be negative")
+		}
+		if c.CreditType != "" && !creditTypes[c.CreditType] {
+			errs.add(field+".credit_type", fmt.Sprintf("must be one of %s, %s, %s, %s",
+				CreditLead, CreditSupporting, CreditCameo, CreditVoice))
+		}
+	}
 }
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilmCreateInput_Validate_Length(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      FilmCreateInput
+		wantFields []string
+	}{
+		{
+			name: "cyrillic name of 150 characters",
+			input: FilmCreateInput{
+				Name: strings.Repeat("ж", 150),
+			},
+			wantFields: nil,
+		},
+		{
+			name: "cyrillic name of 151 characters",
+			input: FilmCreateInput{
+				Name: strings.Repeat("ж", 151),
+			},
+			wantFields: []string{"name"},
+		},
+		{
+			name: "cyrillic description of 1000 characters",
+			input: FilmCreateInput{
+				Name:        "Брат",
+				Description: strings.Repeat("ж", 1000),
+			},
+			wantFields: nil,
+		},
+		{
+			name: "cyrillic description of 1001 characters",
+			input: FilmCreateInput{
+				Name:        "Брат",
+				Description: strings.Repeat("ж", 1001),
+			},
+			wantFields: []string{"description"},
+		},
+		{
+			name: "cyrillic character of 150 characters",
+			input: FilmCreateInput{
+				Name:   "Брат",
+				Actors: []CastInput{{Name: "Сергей Бодров", Character: strings.Repeat("ж", 150)}},
+			},
+			wantFields: nil,
+		},
+		{
+			name: "cyrillic character of 151 characters",
+			input: FilmCreateInput{
+				Name:   "Брат",
+				Actors: []CastInput{{Name: "Сергей Бодров", Character: strings.Repeat("ж", 151)}},
+			},
+			wantFields: []string{"actors[0].character"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.input.CreatedAt = Date(time.Date(1997, time.May, 17, 0, 0, 0, 0, time.UTC))
+
+			err := tc.input.Validate()
+			if tc.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			fields := make([]string, 0, len(validationErr.Fields))
+			for _, f := range validationErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tc.wantFields, fields)
+		})
+	}
+}
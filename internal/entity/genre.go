@@ -0,0 +1,25 @@
+package entity
+
+type Genre struct {
+	Id   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+}
+
+// GenreCount is the number of films of a genre in a film listing.
+type GenreCount struct {
+	Genre
+	Count int `json:"count"`
+}
+
+type GenreInput struct {
+	Name string `json:"name"`
+}
+
+func (form *GenreInput) Validate() error {
+	var errs ValidationError
+	if len(form.Name) < 1 || len(form.Name) > 50 {
+		errs.add("name", "must be from 1 to 50 characters")
+	}
+
+	return errs.err()
+}
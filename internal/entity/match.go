@@ -0,0 +1,43 @@
+package entity
+
+// NameMatch is an actor or a film found by name. Exact is set when the name
+// contains the searched string, otherwise the match is only similar to it.
+type NameMatch struct {
+	Id         int     `json:"id"`
+	Name       string  `json:"name"`
+	Similarity float32 `json:"similarity"`
+	Exact      bool    `json:"-"`
+}
+
+// NameSearchResult holds the exact matches of a name search, DidYouMean is
+// filled with similar names only when there are no exact matches.
+type NameSearchResult struct {
+	Matches    []*NameMatch `json:"matches"`
+	DidYouMean []*NameMatch `json:"did_you_mean,omitempty"`
+}
+
+// NewNameSearchResult splits matches ordered exact first into a search result.
+func NewNameSearchResult(matches []*NameMatch) *NameSearchResult {
+	result := &NameSearchResult{
+		Matches: make([]*NameMatch, 0),
+	}
+	for _, m := range matches {
+		if m.Exact {
+			result.Matches = append(result.Matches, m)
+		}
+	}
+	if len(result.Matches) == 0 {
+		result.DidYouMean = matches
+	}
+
+	return result
+}
+
+// ActorFilms are a page of the films of the actors found by a part of the
+// name. DidYouMean is filled with similar actor names only when no actor name
+// contains the part.
+type ActorFilms struct {
+	Films      []*Film      `json:"films"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	DidYouMean []*NameMatch `json:"did_you_mean,omitempty"`
+}
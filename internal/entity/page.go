@@ -0,0 +1,13 @@
+package entity
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// PageInput describes one page of a keyset paginated listing.
+// Cursor is the opaque next_cursor of the previous page, empty for the first page.
+type PageInput struct {
+	Limit  int
+	Cursor string
+}
@@ -0,0 +1,19 @@
+package entity
+
+// Permissions of user roles. Reading gives access to listings and search,
+// deleting gives access to the trash as well. Revisions name their authors, so
+// reading them is a permission of its own.
+const (
+	PermFilmsRead     = "films:read"
+	PermFilmsWrite    = "films:write"
+	PermFilmsDelete   = "films:delete"
+	PermActorsRead    = "actors:read"
+	PermActorsWrite   = "actors:write"
+	PermActorsDelete  = "actors:delete"
+	PermGenresRead    = "genres:read"
+	PermGenresWrite   = "genres:write"
+	PermGenresDelete  = "genres:delete"
+	PermAuditRead     = "audit:read"
+	PermRevisionsRead = "revisions:read"
+	PermAdminsWrite   = "admins:write"
+)
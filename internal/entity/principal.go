@@ -0,0 +1,12 @@
+package entity
+
+// Principal is the authenticated user on whose behalf a request is made.
+type Principal struct {
+	UserId   int
+	Username string
+	Role     string
+	// TokenId is the id of the access token the request was made with.
+	TokenId string
+	// SessionId is the session the access token was issued for.
+	SessionId int
+}
@@ -0,0 +1,189 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Revision is a numbered state of a film or an actor made by a user. Data is
+// a FilmSnapshot or an ActorSnapshot, it is omitted in revision listings.
+type Revision struct {
+	Number     int             `json:"number" db:"number"`
+	EntityType string          `json:"entity_type" db:"entity_type"`
+	EntityId   int             `json:"entity_id" db:"entity_id"`
+	UserId     int             `json:"user_id" db:"user_id"`
+	Username   string          `json:"username" db:"username"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	Data       json.RawMessage `json:"data,omitempty" db:"data" swaggertype:"object"`
+}
+
+// FilmSnapshot is the editable state of a film kept in its revisions. The
+// cast and the crew are kept by person id, so that a revert links the same
+// people even if they were renamed since. Their names are kept for reading
+// the history only.
+type FilmSnapshot struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	CreatedAt   Date           `json:"created_at" swaggertype:"string" example:"2010-01-01"`
+	Rating      int            `json:"rating"`
+	Actors      []CastSnapshot `json:"actors"`
+	Crew        []CrewSnapshot `json:"crew"`
+	Genres      []int          `json:"genres"`
+}
+
+// CastSnapshot is an actor of a film cast in a revision. ActorId is zero in
+// revisions taken before ids were kept, such an actor is referenced by name.
+type CastSnapshot struct {
+	ActorId    int    `json:"actor_id"`
+	Name       string `json:"name"`
+	Character  string `json:"character"`
+	Billing    int    `json:"billing"`
+	CreditType string `json:"credit_type"`
+}
+
+// CrewSnapshot is a crew member of a film in a revision. PersonId is zero in
+// revisions taken before ids were kept, such a person is referenced by name.
+type CrewSnapshot struct {
+	PersonId int    `json:"person_id"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+}
+
+// NewFilmSnapshot takes the editable state of the film.
+func NewFilmSnapshot(film *Film) *FilmSnapshot {
+	snapshot := &FilmSnapshot{
+		Name:        film.Name,
+		Description: film.Description,
+		CreatedAt:   Date(film.CreatedAt),
+		Rating:      film.Rating,
+		Actors:      make([]CastSnapshot, 0, len(film.Actors)),
+		Crew:        make([]CrewSnapshot, 0, len(film.Crew)),
+		Genres:      make([]int, 0, len(film.Genres)),
+	}
+	for _, a := range film.Actors {
+		snapshot.Actors = append(snapshot.Actors, CastSnapshot{
+			ActorId:    a.Id,
+			Name:       a.Name,
+			Character:  a.Character,
+			Billing:    a.Billing,
+			CreditType: a.CreditType,
+		})
+	}
+	for _, c := range film.Crew {
+		snapshot.Crew = append(snapshot.Crew, CrewSnapshot{PersonId: c.Id, Name: c.Name, Role: c.Role})
+	}
+	for _, g := range film.Genres {
+		snapshot.Genres = append(snapshot.Genres, g.Id)
+	}
+
+	return snapshot
+}
+
+// EditInput makes an input that replaces every field of a film with the
+// ones of the snapshot.
+func (s *FilmSnapshot) EditInput() *FilmEditInput {
+	cast := make([]CastInput, 0, len(s.Actors))
+	for _, a := range s.Actors {
+		cast = append(cast, CastInput{
+			ActorId:    a.ActorId,
+			Name:       a.Name,
+			Character:  a.Character,
+			Billing:    a.Billing,
+			CreditType: a.CreditType,
+		})
+	}
+	crew := make([]CrewInput, 0, len(s.Crew))
+	for _, c := range s.Crew {
+		crew = append(crew, CrewInput{PersonId: c.PersonId, Name: c.Name, Role: c.Role})
+	}
+
+	return &FilmEditInput{
+		Name:        &s.Name,
+		Description: &s.Description,
+		CreatedAt:   &s.CreatedAt,
+		Rating:      &s.Rating,
+		Actors:      &cast,
+		Crew:        &crew,
+		Genres:      &s.Genres,
+	}
+}
+
+// ActorSnapshot is the editable state of an actor kept in its revisions.
+type ActorSnapshot = ActorCreateInput
+
+// NewActorSnapshot takes the editable state of the actor.
+func NewActorSnapshot(actor *Actor) *ActorSnapshot {
+	return &ActorSnapshot{
+		Name:     actor.Name,
+		Gender:   actor.Gender,
+		Birthday: Date(actor.Birthday),
+	}
+}
+
+// EditInput makes an input that replaces every field of the actor with id
+// with the ones of the snapshot.
+func (form *ActorCreateInput) EditInput(id int) *ActorEditInput {
+	return &ActorEditInput{
+		Id:       id,
+		Name:     &form.Name,
+		Gender:   &form.Gender,
+		Birthday: &form.Birthday,
+	}
+}
+
+// FieldChange is a top-level field of a snapshot that differs between two revisions.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from" swaggertype:"object"`
+	To    any    `json:"to" swaggertype:"object"`
+}
+
+// RevisionDiff lists the fields changed from one revision to another, ordered by name.
+type RevisionDiff struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// DiffRevisions compares the snapshots of two revisions of the same entity.
+// A field missing from a snapshot is compared as null.
+func DiffRevisions(from, to *Revision) (*RevisionDiff, error) {
+	var fromData, toData map[string]any
+	if err := json.Unmarshal(from.Data, &fromData); err != nil {
+		return nil, fmt.Errorf("revision %d: %v", from.Number, err)
+	}
+	if err := json.Unmarshal(to.Data, &toData); err != nil {
+		return nil, fmt.Errorf("revision %d: %v", to.Number, err)
+	}
+
+	fields := make([]string, 0, len(fromData))
+	for field := range fromData {
+		fields = append(fields, field)
+	}
+	for field := range toData {
+		if _, ok := fromData[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	diff := &RevisionDiff{
+		From:    from.Number,
+		To:      to.Number,
+		Changes: make([]FieldChange, 0),
+	}
+	for _, field := range fields {
+		if !reflect.DeepEqual(fromData[field], toData[field]) {
+			diff.Changes = append(diff.Changes, FieldChange{
+				Field: field,
+				From:  fromData[field],
+				To:    toData[field],
+			})
+		}
+	}
+
+	return diff, nil
+}
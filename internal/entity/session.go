@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// Session is a sign-in of a user. Its refresh token is replaced on every use,
+// the session is revoked on logout or when a used refresh token comes again.
+type Session struct {
+	Id        int        `db:"id"`
+	UserId    int        `db:"user_id"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+// IsActive reports whether the session can still be used at the time.
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RefreshToken is a stored refresh token with its session and the user the
+// session belongs to. UsedAt is set once the token is exchanged.
+type RefreshToken struct {
+	Session  Session
+	Username string     `db:"username"`
+	Role     string     `db:"role"`
+	UsedAt   *time.Time `db:"used_at"`
+}
+
+// Tokens are issued on sign in and on refresh.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// TrashItem is a deleted film or actor that can be restored or purged.
+type TrashItem struct {
+	Id        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	DeletedAt time.Time `json:"deleted_at" db:"deleted_at"`
+}
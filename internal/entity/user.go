@@ -1,12 +1,20 @@
 package entity
 
+import "unicode/utf8"
+
 type User struct {
-	Id       int    `db:"id"`
-	Username string `db:"username"`
-	Password string `db:"password"`
-	Role     string `db:"role"`
+	Id       int    `json:"id" db:"id"`
+	Username string `json:"username" db:"username"`
+	Password string `json:"-" db:"password"`
+	Role     string `json:"role" db:"role"`
 }
 
+// Roles of users.
+const (
+	UserRoleAdmin = "admin"
+	UserRoleUser  = "user"
+)
+
 type AuthInput struct {
 	Username string
 	Password string
@@ -17,3 +25,22 @@ type CreateInput struct {
 	Password string
 	Role     string
 }
+
+// Password length bounds, the upper one limits the work of hashing.
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 256
+)
+
+// Validate checks the username and the password of a new user of any role.
+func (form *CreateInput) Validate() error {
+	var errs ValidationError
+	if n := utf8.RuneCountInString(form.Username); n < 3 || n > 50 {
+		errs.add("username", "must be from 3 to 50 characters")
+	}
+	if n := utf8.RuneCountInString(form.Password); n < minPasswordLength || n > maxPasswordLength {
+		errs.add("password", "must be from 8 to 256 characters")
+	}
+
+	return errs.err()
+}
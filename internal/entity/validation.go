@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes why a field of an input is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists all invalid fields of an input, so that a client can
+// fix them at once instead of one by one.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		fields = append(fields, fmt.Sprintf("%s: %s", f.Field, f.Message))
+	}
+
+	return fmt.Sprintf("invalid input: %s", strings.Join(fields, "; "))
+}
+
+func (e *ValidationError) add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// err returns nil if no field is invalid.
+func (e *ValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+
+	return e
+}
@@ -2,7 +2,11 @@ package pgdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strings"
+	"time"
 	"vk-film-library/internal/entity"
 	"vk-film-library/internal/repo/repoerrs"
 	"vk-film-library/pkg/postgres"
@@ -22,7 +26,7 @@ func (r *ActorRepo) CreateActor(ctx context.Context, actor *entity.Actor) (int,
 	query := `INSERT INTO actors (name, gender, birthday) VALUES ($1, $2, $3) RETURNING id`
 	var id int
 
-	err := r.client.QueryRow(ctx, query, actor.Name, actor.Gender, actor.Birthday).Scan(&id)
+	err := conn(ctx, r.client).QueryRow(ctx, query, actor.Name, actor.Gender, actor.Birthday).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("ActorRepo CreateActor: %v", err)
 	}
@@ -30,13 +34,26 @@ func (r *ActorRepo) CreateActor(ctx context.Context, actor *entity.Actor) (int,
 	return id, nil
 }
 
-func (r *ActorRepo) GetAllActors(ctx context.Context) ([]*entity.Actor, error) {
-	query := `SELECT id, name, gender, birthday FROM actors`
+func (r *ActorRepo) GetAllActors(ctx context.Context, page *entity.PageInput) ([]*entity.Actor, string, error) {
+	query := `SELECT id, name, gender, birthday FROM actors WHERE deleted_at IS NULL`
+	args := make([]any, 0)
+	if page.Cursor != "" {
+		var cursor actorCursor
+		if err := decodeCursor(page.Cursor, &cursor); err != nil {
+			return nil, "", err
+		}
+
+		args = append(args, cursor.Id)
+		query += ` AND id > $1`
+	}
+	args = append(args, page.Limit+1)
+	query += fmt.Sprintf(` ORDER BY id LIMIT $%d`, len(args))
 
-	rows, err := r.client.Query(ctx, query)
+	rows, err := conn(ctx, r.client).Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("ActorRepo GetAllActors: %v", err)
+		return nil, "", fmt.Errorf("ActorRepo GetAllActors: %v", err)
 	}
+	defer rows.Close()
 
 	actors := make([]*entity.Actor, 0)
 	for rows.Next() {
@@ -44,54 +61,207 @@ func (r *ActorRepo) GetAllActors(ctx context.Context) ([]*entity.Actor, error) {
 
 		err = rows.Scan(&ac.Id, &ac.Name, &ac.Gender, &ac.Birthday)
 		if err != nil {
-			return nil, fmt.Errorf("ActorRepo GetAllActors: %v", err)
+			return nil, "", fmt.Errorf("ActorRepo GetAllActors: %v", err)
 		}
 
 		actors = append(actors, &ac)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("ActorRepo GetAllActors: %v", err)
+		return nil, "", fmt.Errorf("ActorRepo GetAllActors: %v", err)
+	}
+
+	nextCursor := ""
+	if len(actors) > page.Limit {
+		actors = actors[:page.Limit]
+		nextCursor = encodeCursor(actorCursor{Id: actors[len(actors)-1].Id})
+	}
+
+	err = r.loadFilms(ctx, actors)
+	if err != nil {
+		return nil, "", fmt.Errorf("ActorRepo GetAllActors: %v", err)
+	}
+	err = r.loadCredits(ctx, actors)
+	if err != nil {
+		return nil, "", fmt.Errorf("ActorRepo GetAllActors: %v", err)
+	}
+
+	return actors, nextCursor, nil
+}
+
+func (r *ActorRepo) GetActorByID(ctx context.Context, id int) (*entity.Actor, error) {
+	query := `SELECT id, name, gender, birthday FROM actors WHERE id = $1 AND deleted_at IS NULL`
+	var actor entity.Actor
+
+	err := conn(ctx, r.client).QueryRow(ctx, query, id).Scan(&actor.Id, &actor.Name, &actor.Gender, &actor.Birthday)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("ActorRepo GetActorByID: %v", err)
 	}
 
+	actors := []*entity.Actor{&actor}
+	err = r.loadFilms(ctx, actors)
+	if err != nil {
+		return nil, fmt.Errorf("ActorRepo GetActorByID: %v", err)
+	}
+	err = r.loadCredits(ctx, actors)
+	if err != nil {
+		return nil, fmt.Errorf("ActorRepo GetActorByID: %v", err)
+	}
+
+	return &actor, nil
+}
+
+// LockActor locks the row of an actor that is not deleted until the end of the
+// transaction of ctx, so that concurrent changes of the actor wait for it.
+func (r *ActorRepo) LockActor(ctx context.Context, id int) error {
+	query := `SELECT id FROM actors WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	err := conn(ctx, r.client).QueryRow(ctx, query, id).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repoerrs.ErrNotFound
+		}
+		return fmt.Errorf("ActorRepo LockActor: %v", err)
+	}
+
+	return nil
+}
+
+// loadFilms fills the filmographies of all actors with a single query,
+// films of each actor are ordered by release date.
+func (r *ActorRepo) loadFilms(ctx context.Context, actors []*entity.Actor) error {
+	if len(actors) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(actors))
 	for _, ac := range actors {
-		query = `SELECT name FROM films f JOIN films_actors fa ON fa.film_id = f.id WHERE fa.actor_id=$1`
-		rows, err = r.client.Query(ctx, query, ac.Id)
+		ids = append(ids, ac.Id)
+		ac.Films = make([]*entity.ActorFilm, 0)
+	}
+
+	query := `SELECT fa.actor_id, f.id, f.name, f.created_at, f.rating, fa.character, fa.billing, fa.credit_type
+		FROM films_actors fa JOIN films f ON f.id = fa.film_id
+		WHERE fa.actor_id = ANY($1) AND f.deleted_at IS NULL ORDER BY fa.actor_id, f.created_at, f.id`
+
+	rows, err := conn(ctx, r.client).Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	films := make(map[int][]*entity.ActorFilm, len(actors))
+	for rows.Next() {
+		var (
+			actorId int
+			f       entity.ActorFilm
+		)
+
+		err = rows.Scan(&actorId, &f.Id, &f.Name, &f.CreatedAt, &f.Rating, &f.Character, &f.Billing, &f.CreditType)
 		if err != nil {
-			return nil, fmt.Errorf("ActorRepo GetAllActors: %v", err)
+			return err
+		}
+
+		films[actorId] = append(films[actorId], &f)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, ac := range actors {
+		if actorFilms, ok := films[ac.Id]; ok {
+			ac.Films = actorFilms
 		}
+	}
+
+	return nil
+}
 
-		films := make([]string, 0)
-		for rows.Next() {
-			var f string
+// loadCredits fills the films that the actors worked on in roles other than
+// acting with a single query, ordered by release date.
+func (r *ActorRepo) loadCredits(ctx context.Context, actors []*entity.Actor) error {
+	if len(actors) == 0 {
+		return nil
+	}
 
-			err = rows.Scan(&f)
-			if err != nil {
-				return nil, fmt.Errorf("ActorRepo GetAllActors: %v", err)
-			}
+	ids := make([]int, 0, len(actors))
+	for _, ac := range actors {
+		ids = append(ids, ac.Id)
+		ac.Credits = make([]*entity.PersonCredit, 0)
+	}
+
+	query := `SELECT fc.person_id, f.id, f.name, f.created_at, f.rating, fc.role
+		FROM films_crew fc JOIN films f ON f.id = fc.film_id
+		WHERE fc.person_id = ANY($1) AND f.deleted_at IS NULL ORDER BY fc.person_id, f.created_at, f.id, fc.id`
 
-			films = append(films, f)
+	rows, err := conn(ctx, r.client).Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	credits := make(map[int][]*entity.PersonCredit, len(actors))
+	for rows.Next() {
+		var (
+			personId int
+			c        entity.PersonCredit
+		)
+
+		err = rows.Scan(&personId, &c.Id, &c.Name, &c.CreatedAt, &c.Rating, &c.Role)
+		if err != nil {
+			return err
 		}
-		if err = rows.Err(); err != nil {
-			return nil, fmt.Errorf("ActorRepo GetAllActors: %v", err)
+
+		credits[personId] = append(credits[personId], &c)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, ac := range actors {
+		if actorCredits, ok := credits[ac.Id]; ok {
+			ac.Credits = actorCredits
 		}
+	}
 
-		ac.Films = films
+	return nil
+}
+
+func (r *ActorRepo) FindActorsByName(ctx context.Context, name string, limit int) ([]*entity.NameMatch, error) {
+	matches, err := findByName(ctx, r.client, "actors", name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ActorRepo FindActorsByName: %v", err)
 	}
 
-	return actors, nil
+	return matches, nil
 }
 
-func (r *ActorRepo) EditActor(ctx context.Context, actor *entity.Actor) error {
-	fields := ""
-	if actor.Name != "" {
-		fields += fmt.Sprintf("name = %s", actor.Name)
+func (r *ActorRepo) EditActor(ctx context.Context, input *entity.ActorEditInput) error {
+	fields := make([]string, 0)
+	args := []any{input.Id}
+	if input.Name != nil {
+		args = append(args, *input.Name)
+		fields = append(fields, fmt.Sprintf("name = $%d", len(args)))
 	}
-	if actor.Gender != "" {
-		fields += fmt.Sprintf("gender = %s", actor.Gender)
+	if input.Gender != nil {
+		args = append(args, *input.Gender)
+		fields = append(fields, fmt.Sprintf("gender = $%d", len(args)))
+	}
+	if input.Birthday != nil {
+		args = append(args, time.Time(*input.Birthday))
+		fields = append(fields, fmt.Sprintf("birthday = $%d", len(args)))
+	}
+
+	var query string
+	if len(fields) > 0 {
+		query = fmt.Sprintf(`UPDATE actors SET %s WHERE id = $1 AND deleted_at IS NULL`, strings.Join(fields, ", "))
+	} else {
+		query = `SELECT id FROM actors WHERE id = $1 AND deleted_at IS NULL`
 	}
-	query := fmt.Sprintf(`UPDATE actors SET %s WHERE id = $1`, fields)
 
-	commandTag, err := r.client.Exec(ctx, query, actor.Id)
+	commandTag, err := conn(ctx, r.client).Exec(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("ActorRepo EditActor: %v", err)
 	}
@@ -102,10 +272,11 @@ func (r *ActorRepo) EditActor(ctx context.Context, actor *entity.Actor) error {
 	return nil
 }
 
+// DeleteActor moves the actor to the trash, the actor's credits are kept for a restore.
 func (r *ActorRepo) DeleteActor(ctx context.Context, id int) error {
-	query := `DELETE FROM actors WHERE id = $1`
+	query := `UPDATE actors SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`
 
-	commandTag, err := r.client.Exec(ctx, query, id)
+	commandTag, err := conn(ctx, r.client).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("ActorRepo DeleteActor: %v", err)
 	}
@@ -115,3 +286,42 @@ func (r *ActorRepo) DeleteActor(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// GetDeletedActors returns the actors in the trash, recently deleted first.
+func (r *ActorRepo) GetDeletedActors(ctx context.Context) ([]*entity.TrashItem, error) {
+	items, err := getTrash(ctx, r.client, "actors")
+	if err != nil {
+		return nil, fmt.Errorf("ActorRepo GetDeletedActors: %v", err)
+	}
+
+	return items, nil
+}
+
+func (r *ActorRepo) RestoreActor(ctx context.Context, id int) error {
+	query := `UPDATE actors SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	commandTag, err := conn(ctx, r.client).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("ActorRepo RestoreActor: %v", err)
+	}
+	if commandTag.RowsAffected() != 1 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
+
+// PurgeActor permanently deletes an actor from the trash together with the actor's credits.
+func (r *ActorRepo) PurgeActor(ctx context.Context, id int) error {
+	query := `DELETE FROM actors WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	commandTag, err := conn(ctx, r.client).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("ActorRepo PurgeActor: %v", err)
+	}
+	if commandTag.RowsAffected() != 1 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
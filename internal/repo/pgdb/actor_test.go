@@ -3,11 +3,13 @@ package pgdb
 import (
 	"context"
 	"errors"
+	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v2"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
 	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo/repoerrs"
 )
 
 func TestActorRepo_CreateActor(t *testing.T) {
@@ -88,3 +90,294 @@ func TestActorRepo_CreateActor(t *testing.T) {
 		})
 	}
 }
+
+func TestActorRepo_GetActorByID(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		id  int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         *entity.Actor
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "gender", "birthday"}).
+					AddRow(args.id, "asher", "men", testDate("1990-01-01"))
+
+				m.ExpectQuery("SELECT id, name, gender, birthday FROM actors").
+					WithArgs(args.id).
+					WillReturnRows(rows)
+
+				rows = pgxmock.NewRows([]string{"actor_id", "id", "name", "created_at", "rating", "character", "billing", "credit_type"}).
+					AddRow(args.id, 3, "string", testDate("2000-01-01"), 5, "Neo", 1, "lead").
+					AddRow(args.id, 1, "murder", testDate("2010-01-01"), 7, "", 4, "cameo")
+
+				m.ExpectQuery("SELECT fa.actor_id, f.id, f.name, f.created_at, f.rating, fa.character, fa.billing, fa.credit_type").
+					WithArgs([]int{args.id}).
+					WillReturnRows(rows)
+
+				rows = pgxmock.NewRows([]string{"person_id", "id", "name", "created_at", "rating", "role"}).
+					AddRow(args.id, 1, "murder", testDate("2010-01-01"), 7, "director")
+
+				m.ExpectQuery("SELECT fc.person_id, f.id, f.name, f.created_at, f.rating, fc.role").
+					WithArgs([]int{args.id}).
+					WillReturnRows(rows)
+			},
+			want: &entity.Actor{
+				Id:       1,
+				Name:     "asher",
+				Gender:   "men",
+				Birthday: testDate("1990-01-01"),
+				Films: []*entity.ActorFilm{
+					{Id: 3, Name: "string", CreatedAt: testDate("2000-01-01"), Rating: 5, Character: "Neo", Billing: 1, CreditType: "lead"},
+					{Id: 1, Name: "murder", CreatedAt: testDate("2010-01-01"), Rating: 7, Billing: 4, CreditType: "cameo"},
+				},
+				Credits: []*entity.PersonCredit{
+					{Id: 1, Name: "murder", CreatedAt: testDate("2010-01-01"), Rating: 7, Role: "director"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "actor not found",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("SELECT id, name, gender, birthday FROM actors").
+					WithArgs(args.id).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := poolMock
+			actorRepoMock := NewActorRepo(postgresMock)
+
+			got, err := actorRepoMock.GetActorByID(tc.args.ctx, tc.args.id)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestActorRepo_EditActor(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		input *entity.ActorEditInput
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	name := "Keanu Reeves"
+	gender := entity.GenderMale
+	birthday := entity.Date(time.Date(1964, time.September, 2, 0, 0, 0, 0, time.UTC))
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:   context.Background(),
+				input: &entity.ActorEditInput{Id: 1, Name: &name, Gender: &gender, Birthday: &birthday},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE actors SET name = \\$2, gender = \\$3, birthday = \\$4 WHERE id = \\$1").
+					WithArgs(1, name, gender, time.Time(birthday)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "only gender",
+			args: args{
+				ctx:   context.Background(),
+				input: &entity.ActorEditInput{Id: 1, Gender: &gender},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE actors SET gender = \\$2 WHERE id = \\$1").
+					WithArgs(1, gender).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "no fields",
+			args: args{
+				ctx:   context.Background(),
+				input: &entity.ActorEditInput{Id: 1},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("SELECT id FROM actors WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "actor not found",
+			args: args{
+				ctx:   context.Background(),
+				input: &entity.ActorEditInput{Id: 1, Name: &name},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE actors").
+					WithArgs(1, name).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			actorRepoMock := NewActorRepo(poolMock)
+
+			err := actorRepoMock.EditActor(tc.args.ctx, tc.args.input)
+			assert.Equal(t, tc.wantErr, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestActorRepo_DeleteActor(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		id  int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE actors SET deleted_at = now\\(\\) WHERE id = \\$1 AND deleted_at IS NULL").
+					WithArgs(args.id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "actor already deleted",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE actors SET deleted_at = now\\(\\)").
+					WithArgs(args.id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			actorRepoMock := NewActorRepo(poolMock)
+
+			err := actorRepoMock.DeleteActor(tc.args.ctx, tc.args.id)
+			assert.Equal(t, tc.wantErr, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestActorRepo_LockActor(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT id FROM actors WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT id FROM actors").
+					WithArgs(1).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			actorRepoMock := NewActorRepo(poolMock)
+
+			err := actorRepoMock.LockActor(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"vk-film-library/internal/entity"
+	"vk-film-library/pkg/postgres"
+)
+
+type AuditRepo struct {
+	client postgres.Client
+}
+
+func NewAuditRepo(client postgres.Client) *AuditRepo {
+	return &AuditRepo{
+		client: client,
+	}
+}
+
+func (r *AuditRepo) CreateAuditEntry(ctx context.Context, entry *entity.AuditEntry) error {
+	query := `INSERT INTO audit_log (user_id, username, action, entity_type, entity_id, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := conn(ctx, r.client).Exec(ctx, query, entry.UserId, entry.Username, entry.Action, entry.EntityType, entry.EntityId,
+		entry.Before, entry.After)
+	if err != nil {
+		return fmt.Errorf("AuditRepo CreateAuditEntry: %v", err)
+	}
+
+	return nil
+}
+
+// GetAuditEntries returns the entries matching the filter, most recent first.
+func (r *AuditRepo) GetAuditEntries(ctx context.Context, filter *entity.AuditFilter, page *entity.PageInput) ([]*entity.AuditEntry, string, error) {
+	conditions := make([]string, 0)
+	args := make([]any, 0)
+
+	if filter.EntityType != "" {
+		args = append(args, filter.EntityType)
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", len(args)))
+	}
+	if filter.EntityId != 0 {
+		args = append(args, filter.EntityId)
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", len(args)))
+	}
+	if filter.UserId != 0 {
+		args = append(args, filter.UserId)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+	if page.Cursor != "" {
+		var cursor auditCursor
+		if err := decodeCursor(page.Cursor, &cursor); err != nil {
+			return nil, "", err
+		}
+
+		args = append(args, cursor.Id)
+		conditions = append(conditions, fmt.Sprintf("id < $%d", len(args)))
+	}
+
+	query := `SELECT id, user_id, username, action, entity_type, entity_id, created_at, before, after FROM audit_log`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	args = append(args, page.Limit+1)
+	query += fmt.Sprintf(` ORDER BY id DESC LIMIT $%d`, len(args))
+
+	rows, err := conn(ctx, r.client).Query(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("AuditRepo GetAuditEntries: %v", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*entity.AuditEntry, 0)
+	for rows.Next() {
+		var e entity.AuditEntry
+
+		err = rows.Scan(&e.Id, &e.UserId, &e.Username, &e.Action, &e.EntityType, &e.EntityId, &e.CreatedAt,
+			&e.Before, &e.After)
+		if err != nil {
+			return nil, "", fmt.Errorf("AuditRepo GetAuditEntries: %v", err)
+		}
+
+		entries = append(entries, &e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("AuditRepo GetAuditEntries: %v", err)
+	}
+
+	nextCursor := ""
+	if len(entries) > page.Limit {
+		entries = entries[:page.Limit]
+		nextCursor = encodeCursor(auditCursor{Id: entries[len(entries)-1].Id})
+	}
+
+	return entries, nextCursor, nil
+}
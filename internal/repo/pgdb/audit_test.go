@@ -0,0 +1,117 @@
+package pgdb
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo/repoerrs"
+)
+
+var auditColumns = []string{"id", "user_id", "username", "action", "entity_type", "entity_id", "created_at", "before", "after"}
+
+func TestAuditRepo_GetAuditEntries(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		filter *entity.AuditFilter
+		page   *entity.PageInput
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	changedAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		args           args
+		mockBehavior   MockBehavior
+		want           []*entity.AuditEntry
+		wantNextCursor string
+		wantErr        error
+	}{
+		{
+			name: "first page",
+			args: args{
+				ctx:    context.Background(),
+				filter: &entity.AuditFilter{EntityType: entity.EntityFilm, EntityId: 7, UserId: 2, From: from},
+				page:   &entity.PageInput{Limit: 1},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows(auditColumns).
+					AddRow(5, 2, "admin", entity.AuditEdit, entity.EntityFilm, 7, changedAt,
+						json.RawMessage(`{"name":"old"}`), json.RawMessage(`{"name":"new"}`)).
+					AddRow(3, 2, "admin", entity.AuditCreate, entity.EntityFilm, 7, changedAt,
+						json.RawMessage(nil), json.RawMessage(`{"name":"old"}`))
+
+				m.ExpectQuery("SELECT id, user_id, username, action, entity_type, entity_id, created_at, before, after FROM audit_log "+
+					"WHERE entity_type = \\$1 AND entity_id = \\$2 AND user_id = \\$3 AND created_at >= \\$4 ORDER BY id DESC LIMIT \\$5").
+					WithArgs(entity.EntityFilm, 7, 2, from, 2).
+					WillReturnRows(rows)
+			},
+			want: []*entity.AuditEntry{
+				{
+					Id:         5,
+					UserId:     2,
+					Username:   "admin",
+					Action:     entity.AuditEdit,
+					EntityType: entity.EntityFilm,
+					EntityId:   7,
+					CreatedAt:  changedAt,
+					Before:     json.RawMessage(`{"name":"old"}`),
+					After:      json.RawMessage(`{"name":"new"}`),
+				},
+			},
+			wantNextCursor: encodeCursor(auditCursor{Id: 5}),
+			wantErr:        nil,
+		},
+		{
+			name: "next page",
+			args: args{
+				ctx:    context.Background(),
+				filter: &entity.AuditFilter{},
+				page:   &entity.PageInput{Limit: 20, Cursor: encodeCursor(auditCursor{Id: 5})},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("FROM audit_log WHERE id < \\$1 ORDER BY id DESC LIMIT \\$2").
+					WithArgs(5, 21).
+					WillReturnRows(pgxmock.NewRows(auditColumns))
+			},
+			want:           []*entity.AuditEntry{},
+			wantNextCursor: "",
+			wantErr:        nil,
+		},
+		{
+			name: "invalid cursor",
+			args: args{
+				ctx:    context.Background(),
+				filter: &entity.AuditFilter{},
+				page:   &entity.PageInput{Limit: 20, Cursor: "%%%"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {},
+			want:         nil,
+			wantErr:      repoerrs.ErrInvalidCursor,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			auditRepoMock := NewAuditRepo(poolMock)
+
+			got, nextCursor, err := auditRepoMock.GetAuditEntries(tc.args.ctx, tc.args.filter, tc.args.page)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantNextCursor, nextCursor)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
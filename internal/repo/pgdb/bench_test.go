@@ -0,0 +1,108 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v2"
+	"testing"
+	"vk-film-library/internal/entity"
+)
+
+// countingClient counts the statements that a repository sends to the database.
+type countingClient struct {
+	pgxmock.PgxPoolIface
+	queries int
+}
+
+func (c *countingClient) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	c.queries++
+	return c.PgxPoolIface.Exec(ctx, sql, arguments...)
+}
+
+func (c *countingClient) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	c.queries++
+	return c.PgxPoolIface.Query(ctx, sql, args...)
+}
+
+func (c *countingClient) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	c.queries++
+	return c.PgxPoolIface.QueryRow(ctx, sql, args...)
+}
+
+const benchPageLimit = entity.MaxPageLimit
+
+func BenchmarkFilmRepo_GetFilms(b *testing.B) {
+	poolMock, _ := pgxmock.NewPool()
+	defer poolMock.Close()
+	client := &countingClient{PgxPoolIface: poolMock}
+	filmRepo := NewFilmRepo(client)
+
+	filter := &entity.FilmFilter{Sort: []entity.SortKey{{Field: "rating", Desc: true}}}
+	page := &entity.PageInput{Limit: benchPageLimit}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		films := pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating"})
+		actors := pgxmock.NewRows(castColumns)
+		crew := pgxmock.NewRows([]string{"film_id", "id", "name", "role"})
+		genres := pgxmock.NewRows([]string{"film_id", "id", "name"})
+		for id := 1; id <= benchPageLimit; id++ {
+			films.AddRow(id, fmt.Sprintf("film %d", id), "string", testDate("2010-01-01"), 7)
+			for j := 0; j < 3; j++ {
+				actors.AddRow(id, j, fmt.Sprintf("actor %d", j), "", j+1, "supporting")
+			}
+			crew.AddRow(id, 10, "director", "director")
+			genres.AddRow(id, 1, "drama")
+		}
+		poolMock.ExpectQuery("FROM films").WithArgs(pgxmock.AnyArg()).WillReturnRows(films)
+		poolMock.ExpectQuery("FROM films_actors").WithArgs(pgxmock.AnyArg()).WillReturnRows(actors)
+		poolMock.ExpectQuery("FROM films_crew").WithArgs(pgxmock.AnyArg()).WillReturnRows(crew)
+		poolMock.ExpectQuery("FROM films_genres").WithArgs(pgxmock.AnyArg()).WillReturnRows(genres)
+		b.StartTimer()
+
+		_, _, err := filmRepo.GetFilms(context.Background(), filter, page)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(client.queries)/float64(b.N), "queries/op")
+}
+
+func BenchmarkActorRepo_GetAllActors(b *testing.B) {
+	poolMock, _ := pgxmock.NewPool()
+	defer poolMock.Close()
+	client := &countingClient{PgxPoolIface: poolMock}
+	actorRepo := NewActorRepo(client)
+
+	page := &entity.PageInput{Limit: benchPageLimit}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		actors := pgxmock.NewRows([]string{"id", "name", "gender", "birthday"})
+		films := pgxmock.NewRows([]string{"actor_id", "id", "name", "created_at", "rating", "character", "billing", "credit_type"})
+		credits := pgxmock.NewRows([]string{"person_id", "id", "name", "created_at", "rating", "role"})
+		for id := 1; id <= benchPageLimit; id++ {
+			actors.AddRow(id, fmt.Sprintf("actor %d", id), "men", testDate("2000-01-01"))
+			for j := 0; j < 3; j++ {
+				films.AddRow(id, j, fmt.Sprintf("film %d", j), testDate("2010-01-01"), 7, "", 1, "supporting")
+			}
+			credits.AddRow(id, 3, "film 3", testDate("2010-01-01"), 7, "director")
+		}
+		poolMock.ExpectQuery("FROM actors").WithArgs(pgxmock.AnyArg()).WillReturnRows(actors)
+		poolMock.ExpectQuery("FROM films_actors").WithArgs(pgxmock.AnyArg()).WillReturnRows(films)
+		poolMock.ExpectQuery("FROM films_crew").WithArgs(pgxmock.AnyArg()).WillReturnRows(credits)
+		b.StartTimer()
+
+		_, _, err := actorRepo.GetAllActors(context.Background(), page)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(client.queries)/float64(b.N), "queries/op")
+}
@@ -0,0 +1,57 @@
+package pgdb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+	"vk-film-library/internal/repo/repoerrs"
+)
+
+// filmCursor holds the sort keys of the last film of a page.
+type filmCursor struct {
+	Sort      string    `json:"s"`
+	Name      string    `json:"n,omitempty"`
+	CreatedAt time.Time `json:"c"`
+	Rating    int       `json:"r,omitempty"`
+	Id        int       `json:"i"`
+}
+
+func (c *filmCursor) value(field string) any {
+	switch field {
+	case "name":
+		return c.Name
+	case "created_at":
+		return c.CreatedAt
+	case "rating":
+		return c.Rating
+	default:
+		return c.Id
+	}
+}
+
+// actorCursor holds the sort keys of the last actor of a page.
+type actorCursor struct {
+	Id int `json:"i"`
+}
+
+// auditCursor holds the id of the last audit entry of a page.
+type auditCursor struct {
+	Id int `json:"i"`
+}
+
+func encodeCursor(cursor any) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string, cursor any) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return repoerrs.ErrInvalidCursor
+	}
+	if err = json.Unmarshal(data, cursor); err != nil {
+		return repoerrs.ErrInvalidCursor
+	}
+
+	return nil
+}
@@ -2,7 +2,12 @@ package pgdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
+	"html"
+	"strings"
+	"time"
 	"vk-film-library/internal/entity"
 	"vk-film-library/internal/repo/repoerrs"
 	"vk-film-library/pkg/postgres"
@@ -19,110 +24,339 @@ func NewFilmRepo(client postgres.Client) *FilmRepo {
 }
 
 func (r *FilmRepo) CreateFilm(ctx context.Context, film *entity.Film) (int, error) {
+	tx, err := conn(ctx, r.client).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("FilmRepo CreateFilm: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	cast, crew, err := r.resolvePeople(ctx, tx, film.Actors, film.Crew)
+	if err != nil {
+		var notFoundErr *repoerrs.ActorsNotFoundError
+		var ambiguousErr *repoerrs.AmbiguousActorsError
+		if errors.As(err, &notFoundErr) || errors.As(err, &ambiguousErr) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("FilmRepo CreateFilm: %v", err)
+	}
+
+	genreIds := make([]int, 0, len(film.Genres))
+	for _, genre := range film.Genres {
+		genreIds = append(genreIds, genre.Id)
+	}
+
+	genreIds, err = r.getGenreIds(ctx, tx, genreIds)
+	if err != nil {
+		var notFoundErr *repoerrs.GenresNotFoundError
+		if errors.As(err, &notFoundErr) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("FilmRepo CreateFilm: %v", err)
+	}
+
 	query := `INSERT INTO films (name, description, created_at, rating) VALUES ($1, $2, $3, $4) RETURNING id`
 	var id int
 
-	err := r.client.QueryRow(ctx, query, film.Name, film.Description, film.CreatedAt, film.Rating).Scan(&id)
+	err = tx.QueryRow(ctx, query, film.Name, film.Description, film.CreatedAt, film.Rating).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("FilmRepo CreateFilm: %v", err)
 	}
 
-	for _, actor := range film.Actors {
-		id2, err := r.getActorIdByName(ctx, actor)
+	for _, actor := range cast {
+		query = `INSERT INTO films_actors (film_id, actor_id, character, billing, credit_type) VALUES ($1, $2, $3, $4, $5)`
+		_, err = tx.Exec(ctx, query, id, actor.Id, actor.Character, actor.Billing, actor.CreditType)
 		if err != nil {
-			return 0, err
+			return 0, fmt.Errorf("FilmRepo CreateFilm: %v", err)
+		}
+	}
+
+	for _, member := range crew {
+		query = `INSERT INTO films_crew (film_id, person_id, role) VALUES ($1, $2, $3)`
+		_, err = tx.Exec(ctx, query, id, member.Id, member.Role)
+		if err != nil {
+			return 0, fmt.Errorf("FilmRepo CreateFilm: %v", err)
 		}
+	}
 
-		query = `INSERT INTO films_actors (film_id, actor_id) VALUES ($1, $2)`
-		_, err = r.client.Exec(ctx, query, id, id2)
+	for _, genreId := range genreIds {
+		query = `INSERT INTO films_genres (film_id, genre_id) VALUES ($1, $2)`
+		_, err = tx.Exec(ctx, query, id, genreId)
 		if err != nil {
 			return 0, fmt.Errorf("FilmRepo CreateFilm: %v", err)
 		}
 	}
 
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("FilmRepo CreateFilm: %v", err)
+	}
+
 	return id, nil
 }
 
-func (r *FilmRepo) getActorIdByName(ctx context.Context, name string) (int, error) {
-	query := `SELECT id FROM actors WHERE name = $1`
-	var id int
+// resolvePeople fills the person ids of the cast and the crew referenced by
+// name with a single query and checks that the ones referenced by id exist,
+// skipping repeated credits. If some people are unknown it returns
+// *repoerrs.ActorsNotFoundError listing all of them, if some names belong to
+// several people it returns *repoerrs.AmbiguousActorsError.
+func (r *FilmRepo) resolvePeople(ctx context.Context, q querier, cast []*entity.FilmActor,
+	crew []*entity.FilmCrewMember) ([]*entity.FilmActor, []*entity.FilmCrewMember, error) {
+	names := make([]string, 0, len(cast)+len(crew))
+	ids := make([]int, 0, len(cast)+len(crew))
+	for _, actor := range cast {
+		if actor.Id != 0 {
+			ids = append(ids, actor.Id)
+			continue
+		}
+		names = append(names, actor.Name)
+	}
+	for _, member := range crew {
+		if member.Id != 0 {
+			ids = append(ids, member.Id)
+			continue
+		}
+		names = append(names, member.Name)
+	}
 
-	err := r.client.QueryRow(ctx, query, name).Scan(&id)
+	idsByName, err := r.getActorIdsByNames(ctx, q, names)
 	if err != nil {
-		return 0, fmt.Errorf("FilmRepo CreateFilm: %v", err)
+		return nil, nil, err
+	}
+	unknownIds, err := r.getUnknownActorIds(ctx, q, ids)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return id, nil
+	var unknownNames, ambiguousNames []string
+	seenNames := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seenNames[name] {
+			continue
+		}
+		seenNames[name] = true
+
+		switch len(idsByName[name]) {
+		case 0:
+			unknownNames = append(unknownNames, name)
+		case 1:
+		default:
+			ambiguousNames = append(ambiguousNames, name)
+		}
+	}
+	if len(unknownNames) > 0 || len(unknownIds) > 0 {
+		return nil, nil, &repoerrs.ActorsNotFoundError{Names: unknownNames, Ids: unknownIds}
+	}
+	if len(ambiguousNames) > 0 {
+		return nil, nil, &repoerrs.AmbiguousActorsError{Names: ambiguousNames}
+	}
+
+	resolvedCast := make([]*entity.FilmActor, 0, len(cast))
+	seen := make(map[int]bool, len(cast))
+	for _, actor := range cast {
+		id := actor.Id
+		if id == 0 {
+			id = idsByName[actor.Name][0]
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		resolvedActor := *actor
+		resolvedActor.Id = id
+		resolvedCast = append(resolvedCast, &resolvedActor)
+	}
+
+	resolvedCrew := make([]*entity.FilmCrewMember, 0, len(crew))
+	seenCredits := make(map[entity.FilmCrewMember]bool, len(crew))
+	for _, member := range crew {
+		resolvedMember := entity.FilmCrewMember{Id: member.Id, Role: member.Role}
+		if resolvedMember.Id == 0 {
+			resolvedMember.Id = idsByName[member.Name][0]
+		}
+		if seenCredits[resolvedMember] {
+			continue
+		}
+		seenCredits[resolvedMember] = true
+
+		resolvedMember.Name = member.Name
+		resolvedCrew = append(resolvedCrew, &resolvedMember)
+	}
+
+	return resolvedCast, resolvedCrew, nil
 }
 
-func (r *FilmRepo) GetSortFilms(ctx context.Context, sort string) ([]*entity.Film, error) {
-	var query string
-	switch sort {
-	case "rating":
-		query = `SELECT id, name, description, created_at, rating FROM films ORDER BY rating`
-	case "name":
-		query = `SELECT id, name, description, created_at, rating FROM films ORDER BY name`
-	case "created_at":
-		query = `SELECT id, name, description, created_at, rating FROM films ORDER BY created_at`
-	default:
-		return nil, fmt.Errorf("FilmRepo GetFilmsByName: invalid sort field")
+// getActorIdsByNames returns the ids of the people of each name ordered by id.
+// Names are not unique, so a name may have several ids.
+func (r *FilmRepo) getActorIdsByNames(ctx context.Context, q querier, names []string) (map[string][]int, error) {
+	idsByName := make(map[string][]int, len(names))
+	if len(names) == 0 {
+		return idsByName, nil
 	}
 
-	rows, err := r.client.Query(ctx, query)
+	query := `SELECT id, name FROM actors WHERE name = ANY($1) AND deleted_at IS NULL ORDER BY id`
+
+	rows, err := q.Query(ctx, query, names)
 	if err != nil {
-		return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
+		return nil, err
 	}
+	defer rows.Close()
 
-	films := make([]*entity.Film, 0)
 	for rows.Next() {
-		var f entity.Film
+		var (
+			id   int
+			name string
+		)
 
-		err = rows.Scan(&f.Id, &f.Name, &f.Description, &f.CreatedAt, &f.Rating)
+		err = rows.Scan(&id, &name)
 		if err != nil {
-			return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
+			return nil, err
 		}
 
-		films = append(films, &f)
+		idsByName[name] = append(idsByName[name], id)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
+		return nil, err
 	}
 
-	for _, f := range films {
-		query = `SELECT name FROM actors ac JOIN films_actors fa ON fa.actor_id = ac.id WHERE fa.film_id=$1`
-		rows, err = r.client.Query(ctx, query, f.Id)
+	return idsByName, nil
+}
+
+// getUnknownActorIds returns the ids of people that do not exist or are in
+// the trash.
+func (r *FilmRepo) getUnknownActorIds(ctx context.Context, q querier, ids []int) ([]int, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT id FROM actors WHERE id = ANY($1) AND deleted_at IS NULL`
+
+	rows, err := q.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := make(map[int]bool, len(ids))
+	for rows.Next() {
+		var id int
+
+		err = rows.Scan(&id)
 		if err != nil {
-			return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
+			return nil, err
 		}
 
-		actors := make([]string, 0)
-		for rows.Next() {
-			var ac string
-
-			err = rows.Scan(&ac)
-			if err != nil {
-				return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
-			}
+		known[id] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 
-			actors = append(actors, ac)
+	var unknown []int
+	for _, id := range ids {
+		if known[id] {
+			continue
 		}
-		if err = rows.Err(); err != nil {
-			return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
+		known[id] = true
+		unknown = append(unknown, id)
+	}
+
+	return unknown, nil
+}
+
+// getGenreIds checks that all genres exist and returns their ids without
+// duplicates. If some ids are unknown it returns *repoerrs.GenresNotFoundError
+// listing all of them.
+func (r *FilmRepo) getGenreIds(ctx context.Context, q querier, ids []int) ([]int, error) {
+	if len(ids) == 0 {
+		return []int{}, nil
+	}
+
+	query := `SELECT id FROM genres WHERE id = ANY($1)`
+
+	rows, err := q.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := make(map[int]bool, len(ids))
+	for rows.Next() {
+		var id int
+
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
 		}
 
-		f.Actors = actors
+		known[id] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return films, nil
+	genreIds := make([]int, 0, len(ids))
+	seen := make(map[int]bool, len(ids))
+	unknown := make([]int, 0)
+	for _, id := range ids {
+		if !known[id] {
+			unknown = append(unknown, id)
+			continue
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		genreIds = append(genreIds, id)
+	}
+	if len(unknown) > 0 {
+		return nil, &repoerrs.GenresNotFoundError{Ids: unknown}
+	}
+
+	return genreIds, nil
+}
+
+var filmSortColumns = map[string]string{
+	"rating":     "rating",
+	"name":       "name",
+	"created_at": "created_at",
+	"id":         "id",
 }
 
-func (r *FilmRepo) GetFilmsByName(ctx context.Context, namePart string) ([]*entity.Film, error) {
-	query := `SELECT id, name, description, created_at, rating FROM films WHERE name LIKE '%` + namePart + `%'`
+func (r *FilmRepo) GetFilms(ctx context.Context, filter *entity.FilmFilter, page *entity.PageInput) ([]*entity.Film, string, error) {
+	for _, key := range filter.Sort {
+		if _, ok := filmSortColumns[key.Field]; !ok {
+			return nil, "", fmt.Errorf("FilmRepo GetFilms: invalid sort field")
+		}
+	}
+
+	// id is the tie-breaker that makes the order deterministic
+	keys := append(filter.Sort[:len(filter.Sort):len(filter.Sort)], entity.SortKey{Field: "id"})
+	sortName := formatSort(filter.Sort)
+
+	conditions, args := filmFilterConditions(filter)
+	if page != nil && page.Cursor != "" {
+		var cursor filmCursor
+		if err := decodeCursor(page.Cursor, &cursor); err != nil || cursor.Sort != sortName {
+			return nil, "", repoerrs.ErrInvalidCursor
+		}
+
+		var condition string
+		condition, args = keysetCondition(keys, cursor.value, args)
+		conditions = append(conditions, condition)
+	}
+
+	query := `SELECT id, name, description, created_at, rating FROM films WHERE ` + strings.Join(conditions, " AND ")
+	query += ` ORDER BY ` + orderBy(keys)
+	if page != nil {
+		args = append(args, page.Limit+1)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
 
-	rows, err := r.client.Query(ctx, query)
+	rows, err := conn(ctx, r.client).Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
+		return nil, "", fmt.Errorf("FilmRepo GetFilms: %v", err)
 	}
+	defer rows.Close()
 
 	films := make([]*entity.Film, 0)
 	for rows.Next() {
@@ -130,116 +364,720 @@ func (r *FilmRepo) GetFilmsByName(ctx context.Context, namePart string) ([]*enti
 
 		err = rows.Scan(&f.Id, &f.Name, &f.Description, &f.CreatedAt, &f.Rating)
 		if err != nil {
-			return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
+			return nil, "", fmt.Errorf("FilmRepo GetFilms: %v", err)
 		}
 
 		films = append(films, &f)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
+		return nil, "", fmt.Errorf("FilmRepo GetFilms: %v", err)
 	}
 
-	for _, f := range films {
-		query = `SELECT name FROM actors ac JOIN films_actors fa ON fa.actor_id = ac.id WHERE fa.film_id=$1`
-		rows, err = r.client.Query(ctx, query, f.Id)
+	nextCursor := ""
+	if page != nil && len(films) > page.Limit {
+		films = films[:page.Limit]
+		last := films[len(films)-1]
+		nextCursor = encodeCursor(filmCursor{
+			Sort:      sortName,
+			Name:      last.Name,
+			CreatedAt: last.CreatedAt,
+			Rating:    last.Rating,
+			Id:        last.Id,
+		})
+	}
+
+	err = r.loadActors(ctx, films)
+	if err != nil {
+		return nil, "", fmt.Errorf("FilmRepo GetFilms: %v", err)
+	}
+	err = r.loadCrew(ctx, films)
+	if err != nil {
+		return nil, "", fmt.Errorf("FilmRepo GetFilms: %v", err)
+	}
+	err = r.loadGenres(ctx, films)
+	if err != nil {
+		return nil, "", fmt.Errorf("FilmRepo GetFilms: %v", err)
+	}
+
+	return films, nextCursor, nil
+}
+
+// CountFilmGenres counts films of each genre among the films matching the
+// filter. The genre filter itself is not applied, so that selecting a genre
+// does not hide the counts of the others. Genres without films are omitted.
+func (r *FilmRepo) CountFilmGenres(ctx context.Context, filter *entity.FilmFilter) ([]*entity.GenreCount, error) {
+	withoutGenres := *filter
+	withoutGenres.GenreIds = nil
+	conditions, args := filmFilterConditions(&withoutGenres)
+
+	query := `SELECT g.id, g.name, count(*) AS films FROM films_genres fg JOIN genres g ON g.id = fg.genre_id
+		WHERE fg.film_id IN (SELECT id FROM films WHERE ` + strings.Join(conditions, " AND ") + `)
+		GROUP BY g.id, g.name ORDER BY films DESC, g.name`
+
+	rows, err := conn(ctx, r.client).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("FilmRepo CountFilmGenres: %v", err)
+	}
+	defer rows.Close()
+
+	counts := make([]*entity.GenreCount, 0)
+	for rows.Next() {
+		var c entity.GenreCount
+
+		err = rows.Scan(&c.Id, &c.Name, &c.Count)
 		if err != nil {
-			return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
+			return nil, fmt.Errorf("FilmRepo CountFilmGenres: %v", err)
 		}
 
-		actors := make([]string, 0)
-		for rows.Next() {
-			var ac string
+		counts = append(counts, &c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("FilmRepo CountFilmGenres: %v", err)
+	}
 
-			err = rows.Scan(&ac)
-			if err != nil {
-				return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
-			}
+	return counts, nil
+}
 
-			actors = append(actors, ac)
+// filmFilterConditions builds the WHERE conditions of the filter with their bound
+// arguments. Deleted films are always excluded.
+func filmFilterConditions(filter *entity.FilmFilter) ([]string, []any) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := make([]any, 0)
+
+	if filter.Name != "" {
+		args = append(args, containsPattern(filter.Name))
+		conditions = append(conditions, fmt.Sprintf("name ILIKE $%d", len(args)))
+	}
+	if filter.ActorName != "" {
+		args = append(args, containsPattern(filter.ActorName))
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM films_actors fa JOIN actors ac ON ac.id = fa.actor_id `+
+			`WHERE fa.film_id = films.id AND ac.deleted_at IS NULL AND ac.name ILIKE $%d)`, len(args)))
+	}
+	if filter.ActorId != 0 {
+		args = append(args, filter.ActorId)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM films_actors fa JOIN actors ac ON ac.id = fa.actor_id `+
+			`WHERE fa.film_id = films.id AND ac.deleted_at IS NULL AND fa.actor_id = $%d)`, len(args)))
+	}
+	if filter.PersonId != 0 {
+		args = append(args, filter.PersonId)
+		personArg := len(args)
+		crewRole := ""
+		if filter.PersonRole != "" && filter.PersonRole != entity.RoleActor {
+			args = append(args, filter.PersonRole)
+			crewRole = fmt.Sprintf(" AND fc.role = $%d", len(args))
+		}
+		cast := fmt.Sprintf(`EXISTS (SELECT 1 FROM films_actors fa JOIN actors ac ON ac.id = fa.actor_id `+
+			`WHERE fa.film_id = films.id AND ac.deleted_at IS NULL AND fa.actor_id = $%d)`, personArg)
+		crew := fmt.Sprintf(`EXISTS (SELECT 1 FROM films_crew fc JOIN actors p ON p.id = fc.person_id `+
+			`WHERE fc.film_id = films.id AND p.deleted_at IS NULL AND fc.person_id = $%d%s)`, personArg, crewRole)
+
+		switch filter.PersonRole {
+		case entity.RoleActor:
+			conditions = append(conditions, cast)
+		case "":
+			conditions = append(conditions, "("+cast+" OR "+crew+")")
+		default:
+			conditions = append(conditions, crew)
 		}
-		if err = rows.Err(); err != nil {
-			return nil, fmt.Errorf("FilmRepo GetFilmsByName: %v", err)
+	}
+	if len(filter.GenreIds) > 0 {
+		genreIds := uniqueIds(filter.GenreIds)
+		args = append(args, genreIds)
+		if filter.AllGenres {
+			args = append(args, len(genreIds))
+			conditions = append(conditions, fmt.Sprintf(`(SELECT count(*) FROM films_genres fg `+
+				`WHERE fg.film_id = films.id AND fg.genre_id = ANY($%d)) = $%d`, len(args)-1, len(args)))
+		} else {
+			conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM films_genres fg `+
+				`WHERE fg.film_id = films.id AND fg.genre_id = ANY($%d))`, len(args)))
 		}
+	}
+	if filter.MinRating != nil {
+		args = append(args, *filter.MinRating)
+		conditions = append(conditions, fmt.Sprintf("rating >= $%d", len(args)))
+	}
+	if filter.MaxRating != nil {
+		args = append(args, *filter.MaxRating)
+		conditions = append(conditions, fmt.Sprintf("rating <= $%d", len(args)))
+	}
+	if !filter.ReleasedFrom.IsZero() {
+		args = append(args, filter.ReleasedFrom)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !filter.ReleasedTo.IsZero() {
+		args = append(args, filter.ReleasedTo)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
 
-		f.Actors = actors
+	return conditions, args
+}
+
+// uniqueIds returns ids without duplicates keeping their order.
+func uniqueIds(ids []int) []int {
+	unique := make([]int, 0, len(ids))
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
 	}
 
-	return films, nil
+	return unique
 }
 
-func (r *FilmRepo) GetFilmsByActor(ctx context.Context, namePart string) ([]*entity.Film, error) {
-	query := `SELECT fa.film_id FROM films_actors fa JOIN actors ac ON ac.id = fa.actor_id WHERE ac.name LIKE '%` +
-		namePart + `%'`
+// containsPattern makes an ILIKE pattern matching strings that contain s.
+func containsPattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+// orderBy builds the ORDER BY list of the sort keys.
+func orderBy(keys []entity.SortKey) string {
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		column := filmSortColumns[key.Field]
+		if key.Desc {
+			column += " DESC"
+		}
+		columns = append(columns, column)
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+// keysetCondition builds the condition selecting rows that come after the
+// cursor in the order of the keys. Row value comparison cannot be used since
+// the keys may have different directions, so the condition is expanded to
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+func keysetCondition(keys []entity.SortKey, value func(field string) any, args []any) (string, []any) {
+	alternatives := make([]string, 0, len(keys))
+	for i, key := range keys {
+		parts := make([]string, 0, i+1)
+		for _, prev := range keys[:i] {
+			args = append(args, value(prev.Field))
+			parts = append(parts, fmt.Sprintf("%s = $%d", filmSortColumns[prev.Field], len(args)))
+		}
+
+		op := ">"
+		if key.Desc {
+			op = "<"
+		}
+		args = append(args, value(key.Field))
+		parts = append(parts, fmt.Sprintf("%s %s $%d", filmSortColumns[key.Field], op, len(args)))
 
-	rows, err := r.client.Query(ctx, query)
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// formatSort is the inverse of entity.ParseFilmSort.
+func formatSort(keys []entity.SortKey) string {
+	fields := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key.Desc {
+			fields = append(fields, "-"+key.Field)
+		} else {
+			fields = append(fields, key.Field)
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// searchConfigs maps a search language to its text search configuration and tsvector column.
+var searchConfigs = map[string]struct {
+	config string
+	column string
+}{
+	"ru": {config: "russian", column: "search_ru"},
+	"en": {config: "english", column: "search_en"},
+}
+
+// Headlines mark the matched words with control characters that cannot come
+// from HTML. The rest of a headline is HTML-escaped before the marks become
+// <b></b>, so markup stored in a film never reaches a client as markup.
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+var headlineReplacer = strings.NewReplacer(headlineStart, "<b>", headlineStop, "</b>")
+
+func formatHeadline(headline string) string {
+	return headlineReplacer.Replace(html.EscapeString(headline))
+}
+
+func (r *FilmRepo) SearchFilms(ctx context.Context, input *entity.FilmSearchInput) ([]*entity.FilmSearchResult, error) {
+	search, ok := searchConfigs[input.Lang]
+	if !ok {
+		return nil, fmt.Errorf("FilmRepo SearchFilms: invalid search language")
+	}
+
+	query := fmt.Sprintf(`SELECT id, name, description, created_at, rating, ts_rank(%[2]s, q) AS rank,
+		ts_headline('%[1]s', name, q, 'StartSel="%[3]s", StopSel="%[4]s", HighlightAll=true'),
+		ts_headline('%[1]s', description, q, 'StartSel="%[3]s", StopSel="%[4]s", MaxFragments=2, MinWords=5, MaxWords=20')
+		FROM films, websearch_to_tsquery('%[1]s', $1) q
+		WHERE %[2]s @@ q AND deleted_at IS NULL
+		ORDER BY rank DESC, id
+		LIMIT $2`, search.config, search.column, headlineStart, headlineStop)
+
+	rows, err := conn(ctx, r.client).Query(ctx, query, input.Query, input.Limit)
 	if err != nil {
-		return nil, fmt.Errorf("FilmRepo GetFilmsByActor: %v", err)
+		return nil, fmt.Errorf("FilmRepo SearchFilms: %v", err)
 	}
+	defer rows.Close()
 
-	films := make([]*entity.Film, 0)
+	results := make([]*entity.FilmSearchResult, 0)
 	for rows.Next() {
-		var id int
+		f := entity.FilmSearchResult{Film: &entity.Film{}}
 
-		err = rows.Scan(&id)
+		err = rows.Scan(&f.Film.Id, &f.Film.Name, &f.Film.Description, &f.Film.CreatedAt, &f.Film.Rating,
+			&f.Rank, &f.NameHeadline, &f.DescriptionHeadline)
 		if err != nil {
-			return nil, fmt.Errorf("FilmRepo GetFilmsByActor: %v", err)
+			return nil, fmt.Errorf("FilmRepo SearchFilms: %v", err)
 		}
+		f.NameHeadline = formatHeadline(f.NameHeadline)
+		f.DescriptionHeadline = formatHeadline(f.DescriptionHeadline)
+
+		results = append(results, &f)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("FilmRepo SearchFilms: %v", err)
+	}
+
+	films := make([]*entity.Film, 0, len(results))
+	for _, f := range results {
+		films = append(films, f.Film)
+	}
+	err = r.loadActors(ctx, films)
+	if err != nil {
+		return nil, fmt.Errorf("FilmRepo SearchFilms: %v", err)
+	}
+	err = r.loadCrew(ctx, films)
+	if err != nil {
+		return nil, fmt.Errorf("FilmRepo SearchFilms: %v", err)
+	}
+	err = r.loadGenres(ctx, films)
+	if err != nil {
+		return nil, fmt.Errorf("FilmRepo SearchFilms: %v", err)
+	}
+
+	return results, nil
+}
 
-		f, err := r.getFilmById(ctx, id)
+func (r *FilmRepo) FindFilmsByName(ctx context.Context, name string, limit int) ([]*entity.NameMatch, error) {
+	matches, err := findByName(ctx, r.client, "films", name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("FilmRepo FindFilmsByName: %v", err)
+	}
+
+	return matches, nil
+}
+
+func (r *FilmRepo) GetFilmByID(ctx context.Context, id int) (*entity.Film, error) {
+	query := `SELECT id, name, description, created_at, rating FROM films WHERE id = $1 AND deleted_at IS NULL`
+	var film entity.Film
+
+	err := conn(ctx, r.client).QueryRow(ctx, query, id).Scan(&film.Id, &film.Name, &film.Description, &film.CreatedAt, &film.Rating)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("FilmRepo GetFilmByID: %v", err)
+	}
+
+	films := []*entity.Film{&film}
+	err = r.loadActors(ctx, films)
+	if err != nil {
+		return nil, fmt.Errorf("FilmRepo GetFilmByID: %v", err)
+	}
+	err = r.loadCrew(ctx, films)
+	if err != nil {
+		return nil, fmt.Errorf("FilmRepo GetFilmByID: %v", err)
+	}
+	err = r.loadGenres(ctx, films)
+	if err != nil {
+		return nil, fmt.Errorf("FilmRepo GetFilmByID: %v", err)
+	}
+
+	return &film, nil
+}
+
+// loadActors fills the casts of all films with a single query, each cast is ordered by billing.
+func (r *FilmRepo) loadActors(ctx context.Context, films []*entity.Film) error {
+	if len(films) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(films))
+	for _, f := range films {
+		ids = append(ids, f.Id)
+		f.Actors = make([]*entity.FilmActor, 0)
+	}
+
+	query := `SELECT fa.film_id, ac.id, ac.name, fa.character, fa.billing, fa.credit_type
+		FROM films_actors fa JOIN actors ac ON ac.id = fa.actor_id
+		WHERE fa.film_id = ANY($1) AND ac.deleted_at IS NULL ORDER BY fa.film_id, fa.billing, fa.id`
+
+	rows, err := conn(ctx, r.client).Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	actors := make(map[int][]*entity.FilmActor, len(films))
+	for rows.Next() {
+		var (
+			filmId int
+			ac     entity.FilmActor
+		)
+
+		err = rows.Scan(&filmId, &ac.Id, &ac.Name, &ac.Character, &ac.Billing, &ac.CreditType)
 		if err != nil {
-			return nil, fmt.Errorf("FilmRepo GetFilmsByActor: %v", err)
+			return err
 		}
-		f.Id = id
-		films = append(films, f)
+
+		actors[filmId] = append(actors[filmId], &ac)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("FilmRepo GetFilmsByActor: %v", err)
+		return err
 	}
 
 	for _, f := range films {
-		query = `SELECT name FROM actors ac JOIN films_actors fa ON fa.actor_id = ac.id WHERE fa.film_id=$1`
-		rows, err = r.client.Query(ctx, query, f.Id)
+		if filmActors, ok := actors[f.Id]; ok {
+			f.Actors = filmActors
+		}
+	}
+
+	return nil
+}
+
+// loadCrew fills the crews of all films with a single query.
+func (r *FilmRepo) loadCrew(ctx context.Context, films []*entity.Film) error {
+	if len(films) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(films))
+	for _, f := range films {
+		ids = append(ids, f.Id)
+		f.Crew = make([]*entity.FilmCrewMember, 0)
+	}
+
+	query := `SELECT fc.film_id, p.id, p.name, fc.role FROM films_crew fc JOIN actors p ON p.id = fc.person_id
+		WHERE fc.film_id = ANY($1) AND p.deleted_at IS NULL ORDER BY fc.film_id, fc.id`
+
+	rows, err := conn(ctx, r.client).Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	crew := make(map[int][]*entity.FilmCrewMember, len(films))
+	for rows.Next() {
+		var (
+			filmId int
+			member entity.FilmCrewMember
+		)
+
+		err = rows.Scan(&filmId, &member.Id, &member.Name, &member.Role)
 		if err != nil {
-			return nil, fmt.Errorf("FilmRepo GetFilmsByActors: %v", err)
+			return err
+		}
+
+		crew[filmId] = append(crew[filmId], &member)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, f := range films {
+		if filmCrew, ok := crew[f.Id]; ok {
+			f.Crew = filmCrew
+		}
+	}
+
+	return nil
+}
+
+// loadGenres fills the genres of all films with a single query.
+func (r *FilmRepo) loadGenres(ctx context.Context, films []*entity.Film) error {
+	if len(films) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(films))
+	for _, f := range films {
+		ids = append(ids, f.Id)
+		f.Genres = make([]*entity.Genre, 0)
+	}
+
+	query := `SELECT fg.film_id, g.id, g.name FROM films_genres fg JOIN genres g ON g.id = fg.genre_id
+		WHERE fg.film_id = ANY($1) ORDER BY fg.film_id, g.name`
+
+	rows, err := conn(ctx, r.client).Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	genres := make(map[int][]*entity.Genre, len(films))
+	for rows.Next() {
+		var (
+			filmId int
+			g      entity.Genre
+		)
+
+		err = rows.Scan(&filmId, &g.Id, &g.Name)
+		if err != nil {
+			return err
+		}
+
+		genres[filmId] = append(genres[filmId], &g)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, f := range films {
+		if filmGenres, ok := genres[f.Id]; ok {
+			f.Genres = filmGenres
+		}
+	}
+
+	return nil
+}
+
+// LockFilm locks the row of a film that is not deleted until the end of the
+// transaction of ctx, so that concurrent changes of the film wait for it.
+func (r *FilmRepo) LockFilm(ctx context.Context, id int) error {
+	query := `SELECT id FROM films WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	err := conn(ctx, r.client).QueryRow(ctx, query, id).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repoerrs.ErrNotFound
+		}
+		return fmt.Errorf("FilmRepo LockFilm: %v", err)
+	}
+
+	return nil
+}
+
+func (r *FilmRepo) EditFilm(ctx context.Context, id int, input *entity.FilmEditInput) error {
+	tx, err := conn(ctx, r.client).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("FilmRepo EditFilm: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	fields := make([]string, 0)
+	args := []any{id}
+	if input.Name != nil {
+		args = append(args, *input.Name)
+		fields = append(fields, fmt.Sprintf("name = $%d", len(args)))
+	}
+	if input.Description != nil {
+		args = append(args, *input.Description)
+		fields = append(fields, fmt.Sprintf("description = $%d", len(args)))
+	}
+	if input.CreatedAt != nil {
+		args = append(args, time.Time(*input.CreatedAt))
+		fields = append(fields, fmt.Sprintf("created_at = $%d", len(args)))
+	}
+	if input.Rating != nil {
+		args = append(args, *input.Rating)
+		fields = append(fields, fmt.Sprintf("rating = $%d", len(args)))
+	}
+
+	var query string
+	if len(fields) > 0 {
+		query = fmt.Sprintf(`UPDATE films SET %s WHERE id = $1 AND deleted_at IS NULL`, strings.Join(fields, ", "))
+	} else {
+		query = `SELECT id FROM films WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	}
+
+	commandTag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("FilmRepo EditFilm: %v", err)
+	}
+	if commandTag.RowsAffected() != 1 {
+		return repoerrs.ErrNotFound
+	}
+
+	if input.Actors != nil || input.Crew != nil {
+		var cast []*entity.FilmActor
+		if input.Actors != nil {
+			cast = entity.NewFilmCast(*input.Actors)
+		}
+		var crew []*entity.FilmCrewMember
+		if input.Crew != nil {
+			crew = entity.NewFilmCrew(*input.Crew)
 		}
 
-		actors := make([]string, 0)
-		for rows.Next() {
-			var ac string
+		cast, crew, err = r.resolvePeople(ctx, tx, cast, crew)
+		if err != nil {
+			var notFoundErr *repoerrs.ActorsNotFoundError
+			var ambiguousErr *repoerrs.AmbiguousActorsError
+			if errors.As(err, &notFoundErr) || errors.As(err, &ambiguousErr) {
+				return err
+			}
+			return fmt.Errorf("FilmRepo EditFilm: %v", err)
+		}
 
-			err = rows.Scan(&ac)
+		if input.Actors != nil {
+			err = r.setFilmActors(ctx, tx, id, cast)
+			if err != nil {
+				return fmt.Errorf("FilmRepo EditFilm: %v", err)
+			}
+		}
+		if input.Crew != nil {
+			err = r.setFilmCrew(ctx, tx, id, crew)
 			if err != nil {
-				return nil, fmt.Errorf("FilmRepo GetFilmsByActors: %v", err)
+				return fmt.Errorf("FilmRepo EditFilm: %v", err)
 			}
+		}
+	}
 
-			actors = append(actors, ac)
+	if input.Genres != nil {
+		genreIds, err := r.getGenreIds(ctx, tx, *input.Genres)
+		if err != nil {
+			var notFoundErr *repoerrs.GenresNotFoundError
+			if errors.As(err, &notFoundErr) {
+				return err
+			}
+			return fmt.Errorf("FilmRepo EditFilm: %v", err)
 		}
-		if err = rows.Err(); err != nil {
-			return nil, fmt.Errorf("FilmRepo GetFilmsByActors: %v", err)
+
+		err = r.setFilmGenres(ctx, tx, id, genreIds)
+		if err != nil {
+			return fmt.Errorf("FilmRepo EditFilm: %v", err)
 		}
+	}
 
-		f.Actors = actors
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("FilmRepo EditFilm: %v", err)
 	}
 
-	return films, nil
+	return nil
 }
 
-func (r *FilmRepo) getFilmById(ctx context.Context, id int) (*entity.Film, error) {
-	query := `SELECT name, description, created_at, rating FROM films WHERE id = $1`
-	var film entity.Film
+// setFilmActors makes the cast of the film match the given one, inserting,
+// updating and deleting only the films_actors rows that differ. Rows of deleted
+// actors are kept, so that restoring an actor brings them back.
+func (r *FilmRepo) setFilmActors(ctx context.Context, q querier, filmId int, cast []*entity.FilmActor) error {
+	wanted := make(map[int]bool, len(cast))
+	for _, actor := range cast {
+		wanted[actor.Id] = true
+	}
 
-	err := r.client.QueryRow(ctx, query, id).Scan(&film.Name, &film.Description, &film.CreatedAt, &film.Rating)
+	query := `SELECT fa.actor_id, fa.character, fa.billing, fa.credit_type FROM films_actors fa JOIN actors ac ON ac.id = fa.actor_id
+		WHERE fa.film_id = $1 AND ac.deleted_at IS NULL ORDER BY fa.id`
+	rows, err := q.Query(ctx, query, filmId)
 	if err != nil {
-		return nil, fmt.Errorf("FilmRepo GetFilmsByActor: %v", err)
+		return err
 	}
 
-	return &film, nil
+	current := make([]*entity.FilmActor, 0)
+	for rows.Next() {
+		var actor entity.FilmActor
+
+		err = rows.Scan(&actor.Id, &actor.Character, &actor.Billing, &actor.CreditType)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		current = append(current, &actor)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	currentById := make(map[int]*entity.FilmActor, len(current))
+	for _, actor := range current {
+		currentById[actor.Id] = actor
+		if wanted[actor.Id] {
+			continue
+		}
+
+		query = `DELETE FROM films_actors WHERE film_id = $1 AND actor_id = $2`
+		_, err = q.Exec(ctx, query, filmId, actor.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, actor := range cast {
+		old, ok := currentById[actor.Id]
+		if !ok {
+			query = `INSERT INTO films_actors (film_id, actor_id, character, billing, credit_type) VALUES ($1, $2, $3, $4, $5)`
+		} else if old.Character != actor.Character || old.Billing != actor.Billing || old.CreditType != actor.CreditType {
+			query = `UPDATE films_actors SET character = $3, billing = $4, credit_type = $5 WHERE film_id = $1 AND actor_id = $2`
+		} else {
+			continue
+		}
+
+		_, err = q.Exec(ctx, query, filmId, actor.Id, actor.Character, actor.Billing, actor.CreditType)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
+// setFilmCrew makes the crew of the film match the given one, keeping the rows of deleted people.
+func (r *FilmRepo) setFilmCrew(ctx context.Context, q querier, filmId int, crew []*entity.FilmCrewMember) error {
+	personIds := make([]int, 0, len(crew))
+	roles := make([]string, 0, len(crew))
+	for _, member := range crew {
+		personIds = append(personIds, member.Id)
+		roles = append(roles, member.Role)
+	}
+
+	query := `DELETE FROM films_crew fc USING actors p
+		WHERE p.id = fc.person_id AND p.deleted_at IS NULL AND fc.film_id = $1
+		AND (fc.person_id, fc.role) NOT IN (SELECT * FROM unnest($2::int[], $3::text[]))`
+	_, err := q.Exec(ctx, query, filmId, personIds, roles)
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO films_crew (film_id, person_id, role) SELECT $1, * FROM unnest($2::int[], $3::text[]) ON CONFLICT DO NOTHING`
+	_, err = q.Exec(ctx, query, filmId, personIds, roles)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setFilmGenres makes the genres of the film match the given genre ids.
+func (r *FilmRepo) setFilmGenres(ctx context.Context, q querier, filmId int, genreIds []int) error {
+	query := `DELETE FROM films_genres WHERE film_id = $1 AND NOT genre_id = ANY($2)`
+	_, err := q.Exec(ctx, query, filmId, genreIds)
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO films_genres (film_id, genre_id) SELECT $1, unnest($2::int[]) ON CONFLICT DO NOTHING`
+	_, err = q.Exec(ctx, query, filmId, genreIds)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteFilm moves the film to the trash, its cast, crew and genres are kept for a restore.
 func (r *FilmRepo) DeleteFilm(ctx context.Context, id int) error {
-	query := `DELETE FROM films WHERE id = $1`
+	query := `UPDATE films SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`
 
-	commandTag, err := r.client.Exec(ctx, query, id)
+	commandTag, err := conn(ctx, r.client).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("FilmRepo DeleteFilm: %v", err)
 	}
@@ -249,3 +1087,42 @@ func (r *FilmRepo) DeleteFilm(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// GetDeletedFilms returns the films in the trash, recently deleted first.
+func (r *FilmRepo) GetDeletedFilms(ctx context.Context) ([]*entity.TrashItem, error) {
+	items, err := getTrash(ctx, r.client, "films")
+	if err != nil {
+		return nil, fmt.Errorf("FilmRepo GetDeletedFilms: %v", err)
+	}
+
+	return items, nil
+}
+
+func (r *FilmRepo) RestoreFilm(ctx context.Context, id int) error {
+	query := `UPDATE films SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	commandTag, err := conn(ctx, r.client).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("FilmRepo RestoreFilm: %v", err)
+	}
+	if commandTag.RowsAffected() != 1 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
+
+// PurgeFilm permanently deletes a film from the trash together with its cast, crew and genres.
+func (r *FilmRepo) PurgeFilm(ctx context.Context, id int) error {
+	query := `DELETE FROM films WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	commandTag, err := conn(ctx, r.client).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("FilmRepo PurgeFilm: %v", err)
+	}
+	if commandTag.RowsAffected() != 1 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
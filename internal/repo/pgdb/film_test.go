@@ -0,0 +1,1198 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo/repoerrs"
+)
+
+func testDate(s string) time.Time {
+	date, _ := time.Parse(time.DateOnly, s)
+	return date
+}
+
+var castColumns = []string{"film_id", "id", "name", "character", "billing", "credit_type"}
+
+func TestFilmRepo_CreateFilm(t *testing.T) {
+	type args struct {
+		ctx  context.Context
+		film *entity.Film
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx: context.Background(),
+				film: &entity.Film{
+					Name:        "murder",
+					Description: "string",
+					CreatedAt:   testDate("2010-01-01"),
+					Rating:      7,
+					Actors: []*entity.FilmActor{
+						{Name: "asher", Character: "Neo", Billing: 1, CreditType: "lead"},
+						{Name: "lena", Billing: 2, CreditType: "supporting"},
+					},
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectQuery("SELECT id, name FROM actors").
+					WithArgs([]string{"asher", "lena"}).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(2, "asher").AddRow(3, "lena"))
+				m.ExpectQuery("INSERT INTO films").
+					WithArgs(args.film.Name, args.film.Description, args.film.CreatedAt, args.film.Rating).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+				m.ExpectExec("INSERT INTO films_actors").
+					WithArgs(1, 2, "Neo", 1, "lead").
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectExec("INSERT INTO films_actors").
+					WithArgs(1, 3, "", 2, "supporting").
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectCommit()
+			},
+			want:    1,
+			wantErr: nil,
+		},
+		{
+			name: "unknown actors",
+			args: args{
+				ctx: context.Background(),
+				film: &entity.Film{
+					Name:   "murder",
+					Actors: []*entity.FilmActor{{Name: "asher"}, {Name: "lenna"}, {Name: "bob"}},
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectQuery("SELECT id, name FROM actors").
+					WithArgs([]string{"asher", "lenna", "bob"}).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(2, "asher"))
+				m.ExpectRollback()
+			},
+			want:    0,
+			wantErr: &repoerrs.ActorsNotFoundError{Names: []string{"lenna", "bob"}},
+		},
+		{
+			name: "ambiguous actor names",
+			args: args{
+				ctx: context.Background(),
+				film: &entity.Film{
+					Name:   "murder",
+					Actors: []*entity.FilmActor{{Name: "asher"}, {Name: "lena"}},
+					Crew:   []*entity.FilmCrewMember{{Name: "asher", Role: "director"}},
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectQuery("SELECT id, name FROM actors WHERE name = ANY\\(\\$1\\) AND deleted_at IS NULL ORDER BY id").
+					WithArgs([]string{"asher", "lena", "asher"}).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(2, "asher").AddRow(3, "lena").AddRow(4, "asher"))
+				m.ExpectRollback()
+			},
+			want:    0,
+			wantErr: &repoerrs.AmbiguousActorsError{Names: []string{"asher"}},
+		},
+		{
+			name: "with crew",
+			args: args{
+				ctx: context.Background(),
+				film: &entity.Film{
+					Name:   "murder",
+					Actors: []*entity.FilmActor{{Name: "asher", Billing: 1, CreditType: "lead"}},
+					Crew: []*entity.FilmCrewMember{
+						{Name: "asher", Role: "director"},
+						{Name: "lena", Role: "writer"},
+						{Name: "asher", Role: "director"},
+					},
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectQuery("SELECT id, name FROM actors").
+					WithArgs([]string{"asher", "asher", "lena", "asher"}).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(2, "asher").AddRow(3, "lena"))
+				m.ExpectQuery("INSERT INTO films").
+					WithArgs(args.film.Name, args.film.Description, args.film.CreatedAt, args.film.Rating).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+				m.ExpectExec("INSERT INTO films_actors").
+					WithArgs(1, 2, "", 1, "lead").
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectExec("INSERT INTO films_crew").
+					WithArgs(1, 2, "director").
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectExec("INSERT INTO films_crew").
+					WithArgs(1, 3, "writer").
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectCommit()
+			},
+			want:    1,
+			wantErr: nil,
+		},
+		{
+			name: "with genres",
+			args: args{
+				ctx: context.Background(),
+				film: &entity.Film{
+					Name:   "murder",
+					Genres: []*entity.Genre{{Id: 5}, {Id: 6}, {Id: 5}},
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectQuery("SELECT id FROM genres").
+					WithArgs([]int{5, 6, 5}).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(5).AddRow(6))
+				m.ExpectQuery("INSERT INTO films").
+					WithArgs(args.film.Name, args.film.Description, args.film.CreatedAt, args.film.Rating).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+				m.ExpectExec("INSERT INTO films_genres").
+					WithArgs(1, 5).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectExec("INSERT INTO films_genres").
+					WithArgs(1, 6).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectCommit()
+			},
+			want:    1,
+			wantErr: nil,
+		},
+		{
+			name: "unknown genres",
+			args: args{
+				ctx: context.Background(),
+				film: &entity.Film{
+					Name:   "murder",
+					Genres: []*entity.Genre{{Id: 5}, {Id: 7}},
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectQuery("SELECT id FROM genres").
+					WithArgs([]int{5, 7}).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(5))
+				m.ExpectRollback()
+			},
+			want:    0,
+			wantErr: &repoerrs.GenresNotFoundError{Ids: []int{7}},
+		},
+		{
+			name: "unexpected error",
+			args: args{
+				ctx: context.Background(),
+				film: &entity.Film{
+					Name: "murder",
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectQuery("INSERT INTO films").
+					WithArgs(args.film.Name, args.film.Description, args.film.CreatedAt, args.film.Rating).
+					WillReturnError(errors.New("some error"))
+				m.ExpectRollback()
+			},
+			want:    0,
+			wantErr: errors.New("FilmRepo CreateFilm: some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := poolMock
+			filmRepoMock := NewFilmRepo(postgresMock)
+
+			got, err := filmRepoMock.CreateFilm(tc.args.ctx, tc.args.film)
+			if tc.wantErr != nil {
+				assert.Equal(t, tc.wantErr, err)
+				assert.NoError(t, poolMock.ExpectationsWereMet())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFilmRepo_GetFilmByID(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		id  int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         *entity.Film
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating"}).
+					AddRow(args.id, "murder", "string", testDate("2010-01-01"), 7)
+
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films").
+					WithArgs(args.id).
+					WillReturnRows(rows)
+
+				rows = pgxmock.NewRows(castColumns).
+					AddRow(args.id, 2, "asher", "Neo", 1, "lead").
+					AddRow(args.id, 3, "lena", "", 2, "cameo")
+
+				m.ExpectQuery("SELECT fa.film_id, ac.id, ac.name, fa.character, fa.billing, fa.credit_type").
+					WithArgs([]int{args.id}).
+					WillReturnRows(rows)
+
+				rows = pgxmock.NewRows([]string{"film_id", "id", "name"}).
+					AddRow(args.id, 5, "thriller")
+
+				m.ExpectQuery("SELECT fc.film_id, p.id, p.name, fc.role FROM films_crew").
+					WithArgs([]int{args.id}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name", "role"}).AddRow(args.id, 4, "lana", "director"))
+				m.ExpectQuery("SELECT fg.film_id, g.id, g.name FROM films_genres").
+					WithArgs([]int{args.id}).
+					WillReturnRows(rows)
+			},
+			want: &entity.Film{
+				Id:          1,
+				Name:        "murder",
+				Description: "string",
+				CreatedAt:   testDate("2010-01-01"),
+				Rating:      7,
+				Actors: []*entity.FilmActor{
+					{Id: 2, Name: "asher", Character: "Neo", Billing: 1, CreditType: "lead"},
+					{Id: 3, Name: "lena", Billing: 2, CreditType: "cameo"},
+				},
+				Crew: []*entity.FilmCrewMember{
+					{Id: 4, Name: "lana", Role: "director"},
+				},
+				Genres: []*entity.Genre{
+					{Id: 5, Name: "thriller"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "film not found",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films").
+					WithArgs(args.id).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "unexpected error",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films").
+					WithArgs(args.id).
+					WillReturnError(errors.New("some error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := poolMock
+			filmRepoMock := NewFilmRepo(postgresMock)
+
+			got, err := filmRepoMock.GetFilmByID(tc.args.ctx, tc.args.id)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFilmRepo_EditFilm(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		id    int
+		input *entity.FilmEditInput
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	name := "murder 2"
+	rating := 8
+	actors := []entity.CastInput{{Name: "asher", Character: "Neo"}, {Name: "lena"}}
+	actorsByIds := []entity.CastInput{{ActorId: 2, Character: "Neo"}, {Name: "lena"}}
+	unknownActorIds := []entity.CastInput{{ActorId: 2}, {ActorId: 5}}
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+				input: &entity.FilmEditInput{
+					Name:   &name,
+					Rating: &rating,
+					Actors: &actors,
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE films SET name = \\$2, rating = \\$3 WHERE id = \\$1").
+					WithArgs(args.id, name, rating).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				m.ExpectQuery("SELECT id, name FROM actors").
+					WithArgs([]string{"asher", "lena"}).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(2, "asher").AddRow(3, "lena"))
+				m.ExpectQuery("SELECT fa.actor_id, fa.character, fa.billing, fa.credit_type FROM films_actors fa .+ac.deleted_at IS NULL").
+					WithArgs(args.id).
+					WillReturnRows(pgxmock.NewRows([]string{"actor_id", "character", "billing", "credit_type"}).
+						AddRow(2, "", 1, "supporting").
+						AddRow(4, "", 2, "supporting"))
+				m.ExpectExec("DELETE FROM films_actors").
+					WithArgs(args.id, 4).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				m.ExpectExec("UPDATE films_actors SET character = \\$3, billing = \\$4, credit_type = \\$5").
+					WithArgs(args.id, 2, "Neo", 1, "supporting").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				m.ExpectExec("INSERT INTO films_actors").
+					WithArgs(args.id, 3, "", 2, "supporting").
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "actors by id",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+				input: &entity.FilmEditInput{
+					Actors: &actorsByIds,
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectExec("SELECT id FROM films WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+					WithArgs(args.id).
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+				m.ExpectQuery("SELECT id, name FROM actors WHERE name = ANY\\(\\$1\\)").
+					WithArgs([]string{"lena"}).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(3, "lena"))
+				m.ExpectQuery("SELECT id FROM actors WHERE id = ANY\\(\\$1\\) AND deleted_at IS NULL").
+					WithArgs([]int{2}).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+				m.ExpectQuery("SELECT fa.actor_id, fa.character, fa.billing, fa.credit_type FROM films_actors fa").
+					WithArgs(args.id).
+					WillReturnRows(pgxmock.NewRows([]string{"actor_id", "character", "billing", "credit_type"}))
+				m.ExpectExec("INSERT INTO films_actors").
+					WithArgs(args.id, 2, "Neo", 1, "supporting").
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectExec("INSERT INTO films_actors").
+					WithArgs(args.id, 3, "", 2, "supporting").
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown actor id",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+				input: &entity.FilmEditInput{
+					Actors: &unknownActorIds,
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectExec("SELECT id FROM films WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+					WithArgs(args.id).
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+				m.ExpectQuery("SELECT id FROM actors WHERE id = ANY\\(\\$1\\) AND deleted_at IS NULL").
+					WithArgs([]int{2, 5}).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(2))
+				m.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "film not found",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+				input: &entity.FilmEditInput{
+					Rating: &rating,
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE films SET rating = \\$2 WHERE id = \\$1").
+					WithArgs(args.id, rating).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				m.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "unexpected error",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+				input: &entity.FilmEditInput{
+					Rating: &rating,
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE films").
+					WithArgs(args.id, rating).
+					WillReturnError(errors.New("some error"))
+				m.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := poolMock
+			filmRepoMock := NewFilmRepo(postgresMock)
+
+			err := filmRepoMock.EditFilm(tc.args.ctx, tc.args.id, tc.args.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.NoError(t, poolMock.ExpectationsWereMet())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFilmRepo_GetFilms(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		filter *entity.FilmFilter
+		page   *entity.PageInput
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	minRating := 7
+
+	testCases := []struct {
+		name           string
+		args           args
+		mockBehavior   MockBehavior
+		want           []*entity.Film
+		wantNextCursor string
+		wantErr        bool
+	}{
+		{
+			name: "first page",
+			args: args{
+				ctx:    context.Background(),
+				filter: &entity.FilmFilter{Sort: []entity.SortKey{{Field: "rating"}}},
+				page:   &entity.PageInput{Limit: 1},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating"}).
+					AddRow(3, "string", "string", testDate("2000-01-01"), 5).
+					AddRow(1, "murder", "string", testDate("2010-01-01"), 7)
+
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films WHERE deleted_at IS NULL ORDER BY rating, id LIMIT \\$1").
+					WithArgs(2).
+					WillReturnRows(rows)
+				m.ExpectQuery("SELECT fa.film_id, ac.id, ac.name, fa.character, fa.billing, fa.credit_type").
+					WithArgs([]int{3}).
+					WillReturnRows(pgxmock.NewRows(castColumns))
+				m.ExpectQuery("SELECT fc.film_id, p.id, p.name, fc.role FROM films_crew").
+					WithArgs([]int{3}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name", "role"}))
+				m.ExpectQuery("SELECT fg.film_id, g.id, g.name FROM films_genres").
+					WithArgs([]int{3}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name"}))
+			},
+			want: []*entity.Film{
+				{Id: 3, Name: "string", Description: "string", CreatedAt: testDate("2000-01-01"), Rating: 5, Actors: []*entity.FilmActor{}, Crew: []*entity.FilmCrewMember{}, Genres: []*entity.Genre{}},
+			},
+			wantNextCursor: encodeCursor(filmCursor{Sort: "rating", Name: "string", CreatedAt: testDate("2000-01-01"), Rating: 5, Id: 3}),
+			wantErr:        false,
+		},
+		{
+			name: "last page",
+			args: args{
+				ctx:    context.Background(),
+				filter: &entity.FilmFilter{Sort: []entity.SortKey{{Field: "rating"}}},
+				page: &entity.PageInput{
+					Limit:  1,
+					Cursor: encodeCursor(filmCursor{Sort: "rating", Name: "string", CreatedAt: testDate("2000-01-01"), Rating: 5, Id: 3}),
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating"}).
+					AddRow(1, "murder", "string", testDate("2010-01-01"), 7)
+
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films "+
+					"WHERE deleted_at IS NULL AND \\(\\(rating > \\$1\\) OR \\(rating = \\$2 AND id > \\$3\\)\\) ORDER BY rating, id LIMIT \\$4").
+					WithArgs(5, 5, 3, 2).
+					WillReturnRows(rows)
+				m.ExpectQuery("SELECT fa.film_id, ac.id, ac.name, fa.character, fa.billing, fa.credit_type").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows(castColumns).AddRow(1, 2, "asher", "Neo", 1, "lead"))
+				m.ExpectQuery("SELECT fc.film_id, p.id, p.name, fc.role FROM films_crew").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name", "role"}))
+				m.ExpectQuery("SELECT fg.film_id, g.id, g.name FROM films_genres").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name"}))
+			},
+			want: []*entity.Film{
+				{Id: 1, Name: "murder", Description: "string", CreatedAt: testDate("2010-01-01"), Rating: 7, Actors: []*entity.FilmActor{{Id: 2, Name: "asher", Character: "Neo", Billing: 1, CreditType: "lead"}}, Crew: []*entity.FilmCrewMember{}, Genres: []*entity.Genre{}},
+			},
+			wantNextCursor: "",
+			wantErr:        false,
+		},
+		{
+			name: "descending keys",
+			args: args{
+				ctx:    context.Background(),
+				filter: &entity.FilmFilter{Sort: []entity.SortKey{{Field: "rating", Desc: true}, {Field: "created_at", Desc: true}}},
+				page: &entity.PageInput{
+					Limit:  1,
+					Cursor: encodeCursor(filmCursor{Sort: "-rating,-created_at", Name: "murder2", CreatedAt: testDate("2015-01-01"), Rating: 8, Id: 2}),
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating"}).
+					AddRow(1, "murder", "string", testDate("2010-01-01"), 7)
+
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films "+
+					"WHERE deleted_at IS NULL AND \\(\\(rating < \\$1\\) OR \\(rating = \\$2 AND created_at < \\$3\\) "+
+					"OR \\(rating = \\$4 AND created_at = \\$5 AND id > \\$6\\)\\) "+
+					"ORDER BY rating DESC, created_at DESC, id LIMIT \\$7").
+					WithArgs(8, 8, testDate("2015-01-01"), 8, testDate("2015-01-01"), 2, 2).
+					WillReturnRows(rows)
+				m.ExpectQuery("SELECT fa.film_id, ac.id, ac.name, fa.character, fa.billing, fa.credit_type").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows(castColumns))
+				m.ExpectQuery("SELECT fc.film_id, p.id, p.name, fc.role FROM films_crew").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name", "role"}))
+				m.ExpectQuery("SELECT fg.film_id, g.id, g.name FROM films_genres").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name"}))
+			},
+			want: []*entity.Film{
+				{Id: 1, Name: "murder", Description: "string", CreatedAt: testDate("2010-01-01"), Rating: 7, Actors: []*entity.FilmActor{}, Crew: []*entity.FilmCrewMember{}, Genres: []*entity.Genre{}},
+			},
+			wantNextCursor: "",
+			wantErr:        false,
+		},
+		{
+			name: "filter without page",
+			args: args{
+				ctx: context.Background(),
+				filter: &entity.FilmFilter{
+					Name:         "mur%",
+					ActorName:    "ash",
+					MinRating:    &minRating,
+					ReleasedFrom: testDate("2000-01-01"),
+					Sort:         []entity.SortKey{{Field: "rating", Desc: true}},
+				},
+				page: nil,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating"}).
+					AddRow(1, "mur%der", "string", testDate("2010-01-01"), 7)
+
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films "+
+					"WHERE deleted_at IS NULL AND name ILIKE \\$1 AND EXISTS \\(.+ac.name ILIKE \\$2\\) AND rating >= \\$3 AND created_at >= \\$4 "+
+					"ORDER BY rating DESC, id$").
+					WithArgs(`%mur\%%`, "%ash%", minRating, testDate("2000-01-01")).
+					WillReturnRows(rows)
+				m.ExpectQuery("SELECT fa.film_id, ac.id, ac.name, fa.character, fa.billing, fa.credit_type").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows(castColumns).AddRow(1, 2, "asher", "Neo", 1, "lead"))
+				m.ExpectQuery("SELECT fc.film_id, p.id, p.name, fc.role FROM films_crew").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name", "role"}))
+				m.ExpectQuery("SELECT fg.film_id, g.id, g.name FROM films_genres").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name"}))
+			},
+			want: []*entity.Film{
+				{Id: 1, Name: "mur%der", Description: "string", CreatedAt: testDate("2010-01-01"), Rating: 7, Actors: []*entity.FilmActor{{Id: 2, Name: "asher", Character: "Neo", Billing: 1, CreditType: "lead"}}, Crew: []*entity.FilmCrewMember{}, Genres: []*entity.Genre{}},
+			},
+			wantNextCursor: "",
+			wantErr:        false,
+		},
+		{
+			name: "all genres",
+			args: args{
+				ctx: context.Background(),
+				filter: &entity.FilmFilter{
+					GenreIds:  []int{5, 6, 5},
+					AllGenres: true,
+				},
+				page: nil,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating"}).
+					AddRow(1, "murder", "string", testDate("2010-01-01"), 7)
+
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films "+
+					"WHERE deleted_at IS NULL AND \\(SELECT count\\(\\*\\) FROM films_genres fg .+fg.genre_id = ANY\\(\\$1\\)\\) = \\$2 ORDER BY id$").
+					WithArgs([]int{5, 6}, 2).
+					WillReturnRows(rows)
+				m.ExpectQuery("SELECT fa.film_id, ac.id, ac.name, fa.character, fa.billing, fa.credit_type").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows(castColumns))
+				m.ExpectQuery("SELECT fc.film_id, p.id, p.name, fc.role FROM films_crew").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name", "role"}))
+				m.ExpectQuery("SELECT fg.film_id, g.id, g.name FROM films_genres").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name"}).AddRow(1, 5, "drama").AddRow(1, 6, "thriller"))
+			},
+			want: []*entity.Film{
+				{Id: 1, Name: "murder", Description: "string", CreatedAt: testDate("2010-01-01"), Rating: 7, Actors: []*entity.FilmActor{},
+					Crew: []*entity.FilmCrewMember{}, Genres: []*entity.Genre{{Id: 5, Name: "drama"}, {Id: 6, Name: "thriller"}}},
+			},
+			wantNextCursor: "",
+			wantErr:        false,
+		},
+		{
+			name: "person in role",
+			args: args{
+				ctx: context.Background(),
+				filter: &entity.FilmFilter{
+					PersonId:   4,
+					PersonRole: entity.RoleDirector,
+				},
+				page: nil,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films "+
+					"WHERE deleted_at IS NULL AND EXISTS \\(SELECT 1 FROM films_crew fc JOIN actors p ON p.id = fc.person_id "+
+					"WHERE fc.film_id = films.id AND p.deleted_at IS NULL AND fc.person_id = \\$1 AND fc.role = \\$2\\) ORDER BY id$").
+					WithArgs(4, "director").
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating"}))
+			},
+			want:           []*entity.Film{},
+			wantNextCursor: "",
+			wantErr:        false,
+		},
+		{
+			name: "crew role with rating",
+			args: args{
+				ctx: context.Background(),
+				filter: &entity.FilmFilter{
+					PersonId:   4,
+					PersonRole: entity.RoleWriter,
+					MinRating:  &minRating,
+				},
+				page: nil,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films "+
+					"WHERE deleted_at IS NULL AND EXISTS \\(SELECT 1 FROM films_crew fc JOIN actors p ON p.id = fc.person_id "+
+					"WHERE fc.film_id = films.id AND p.deleted_at IS NULL AND fc.person_id = \\$1 AND fc.role = \\$2\\) "+
+					"AND rating >= \\$3 ORDER BY id$").
+					WithArgs(4, "writer", minRating).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating"}))
+			},
+			want:           []*entity.Film{},
+			wantNextCursor: "",
+			wantErr:        false,
+		},
+		{
+			name: "actor id",
+			args: args{
+				ctx:    context.Background(),
+				filter: &entity.FilmFilter{ActorId: 4},
+				page:   nil,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films " +
+					"WHERE deleted_at IS NULL AND EXISTS \\(SELECT 1 FROM films_actors fa JOIN actors ac ON ac.id = fa.actor_id " +
+					"WHERE fa.film_id = films.id AND ac.deleted_at IS NULL AND fa.actor_id = \\$1\\) ORDER BY id$").
+					WithArgs(4).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating"}))
+			},
+			want:           []*entity.Film{},
+			wantNextCursor: "",
+			wantErr:        false,
+		},
+		{
+			name: "person in any role",
+			args: args{
+				ctx:    context.Background(),
+				filter: &entity.FilmFilter{PersonId: 4},
+				page:   nil,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("SELECT id, name, description, created_at, rating FROM films " +
+					"WHERE deleted_at IS NULL AND \\(EXISTS \\(.+ac.deleted_at IS NULL AND fa.actor_id = \\$1\\) " +
+					"OR EXISTS \\(.+p.deleted_at IS NULL AND fc.person_id = \\$1\\)\\) ORDER BY id$").
+					WithArgs(4).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating"}))
+			},
+			want:           []*entity.Film{},
+			wantNextCursor: "",
+			wantErr:        false,
+		},
+		{
+			name: "cursor of another sort",
+			args: args{
+				ctx:    context.Background(),
+				filter: &entity.FilmFilter{Sort: []entity.SortKey{{Field: "name"}}},
+				page: &entity.PageInput{
+					Limit:  1,
+					Cursor: encodeCursor(filmCursor{Sort: "rating", Rating: 5, Id: 3}),
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {},
+			want:         nil,
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := poolMock
+			filmRepoMock := NewFilmRepo(postgresMock)
+
+			got, nextCursor, err := filmRepoMock.GetFilms(tc.args.ctx, tc.args.filter, tc.args.page)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantNextCursor, nextCursor)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFilmRepo_CountFilmGenres(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		filter *entity.FilmFilter
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []*entity.GenreCount
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx: context.Background(),
+				filter: &entity.FilmFilter{
+					Name:     "mur",
+					GenreIds: []int{5},
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "films"}).
+					AddRow(6, "thriller", 3).
+					AddRow(5, "drama", 1)
+
+				m.ExpectQuery("SELECT g.id, g.name, count\\(\\*\\) AS films FROM films_genres fg JOIN genres g ON g.id = fg.genre_id " +
+					"WHERE fg.film_id IN \\(SELECT id FROM films WHERE deleted_at IS NULL AND name ILIKE \\$1\\) GROUP BY g.id, g.name ORDER BY films DESC, g.name").
+					WithArgs("%mur%").
+					WillReturnRows(rows)
+			},
+			want: []*entity.GenreCount{
+				{Genre: entity.Genre{Id: 6, Name: "thriller"}, Count: 3},
+				{Genre: entity.Genre{Id: 5, Name: "drama"}, Count: 1},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unexpected error",
+			args: args{
+				ctx:    context.Background(),
+				filter: &entity.FilmFilter{},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("FROM films_genres fg JOIN genres g ON g.id = fg.genre_id GROUP BY").
+					WillReturnError(errors.New("some error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			filmRepoMock := NewFilmRepo(poolMock)
+
+			got, err := filmRepoMock.CountFilmGenres(tc.args.ctx, tc.args.filter)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFilmRepo_SearchFilms(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		input *entity.FilmSearchInput
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []*entity.FilmSearchResult
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:   context.Background(),
+				input: &entity.FilmSearchInput{Query: "убийство", Lang: "ru", Limit: 20},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "description", "created_at", "rating", "rank", "ts_headline", "ts_headline"}).
+					AddRow(1, "Убийство", "<script>x</script> убийство", testDate("2010-01-01"), 7, float32(0.6),
+						"\x02Убийство\x03", "<script>x</script> \x02убийство\x03")
+
+				m.ExpectQuery("FROM films, websearch_to_tsquery\\('russian', \\$1\\) q\\s+WHERE search_ru @@ q").
+					WithArgs(args.input.Query, args.input.Limit).
+					WillReturnRows(rows)
+				m.ExpectQuery("SELECT fa.film_id, ac.id, ac.name, fa.character, fa.billing, fa.credit_type").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows(castColumns))
+				m.ExpectQuery("SELECT fc.film_id, p.id, p.name, fc.role FROM films_crew").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name", "role"}))
+				m.ExpectQuery("SELECT fg.film_id, g.id, g.name FROM films_genres").
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows([]string{"film_id", "id", "name"}))
+			},
+			want: []*entity.FilmSearchResult{
+				{
+					Film: &entity.Film{
+						Id:          1,
+						Name:        "Убийство",
+						Description: "<script>x</script> убийство",
+						CreatedAt:   testDate("2010-01-01"),
+						Rating:      7,
+						Actors:      []*entity.FilmActor{},
+						Crew:        []*entity.FilmCrewMember{},
+						Genres:      []*entity.Genre{},
+					},
+					Rank:                0.6,
+					NameHeadline:        "<b>Убийство</b>",
+					DescriptionHeadline: "&lt;script&gt;x&lt;/script&gt; <b>убийство</b>",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid language",
+			args: args{
+				ctx:   context.Background(),
+				input: &entity.FilmSearchInput{Query: "murder", Lang: "english'); --", Limit: 20},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {},
+			want:         nil,
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := poolMock
+			filmRepoMock := NewFilmRepo(postgresMock)
+
+			got, err := filmRepoMock.SearchFilms(tc.args.ctx, tc.args.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFilmRepo_FindFilmsByName(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		name  string
+		limit int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []*entity.NameMatch
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:   context.Background(),
+				name:  "mruder",
+				limit: 10,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+				m.ExpectExec("SELECT set_config").
+					WithArgs("0.3").
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+
+				rows := pgxmock.NewRows([]string{"id", "name", "similarity", "exact"}).
+					AddRow(1, "murder", float32(0.4), false)
+
+				m.ExpectQuery("FROM films\\s+WHERE deleted_at IS NULL AND \\(name ILIKE \\$2 OR \\$1 <% name\\)").
+					WithArgs(args.name, "%mruder%", args.limit).
+					WillReturnRows(rows)
+				m.ExpectRollback()
+			},
+			want: []*entity.NameMatch{
+				{Id: 1, Name: "murder", Similarity: 0.4, Exact: false},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unexpected error",
+			args: args{
+				ctx:   context.Background(),
+				name:  "mruder",
+				limit: 10,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+				m.ExpectExec("SELECT set_config").
+					WithArgs("0.3").
+					WillReturnError(errors.New("some error"))
+				m.ExpectRollback()
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := poolMock
+			filmRepoMock := NewFilmRepo(postgresMock)
+
+			got, err := filmRepoMock.FindFilmsByName(tc.args.ctx, tc.args.name, tc.args.limit)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFilmRepo_RestoreFilm(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		id  int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE films SET deleted_at = NULL WHERE id = \\$1 AND deleted_at IS NOT NULL").
+					WithArgs(args.id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "film not in trash",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE films SET deleted_at = NULL").
+					WithArgs(args.id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			filmRepoMock := NewFilmRepo(poolMock)
+
+			err := filmRepoMock.RestoreFilm(tc.args.ctx, tc.args.id)
+			assert.Equal(t, tc.wantErr, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFilmRepo_LockFilm(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT id FROM films WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT id FROM films").
+					WithArgs(1).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			filmRepoMock := NewFilmRepo(poolMock)
+
+			err := filmRepoMock.LockFilm(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
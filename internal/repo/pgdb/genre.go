@@ -0,0 +1,116 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo/repoerrs"
+	"vk-film-library/pkg/postgres"
+)
+
+type GenreRepo struct {
+	client postgres.Client
+}
+
+func NewGenreRepo(client postgres.Client) *GenreRepo {
+	return &GenreRepo{
+		client: client,
+	}
+}
+
+func (r *GenreRepo) CreateGenre(ctx context.Context, genre *entity.Genre) (int, error) {
+	query := `INSERT INTO genres (name) VALUES ($1) RETURNING id`
+	var id int
+
+	err := r.client.QueryRow(ctx, query, genre.Name).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, repoerrs.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("GenreRepo CreateGenre: %v", err)
+	}
+
+	return id, nil
+}
+
+func (r *GenreRepo) GetGenreByID(ctx context.Context, id int) (*entity.Genre, error) {
+	query := `SELECT id, name FROM genres WHERE id = $1`
+	var genre entity.Genre
+
+	err := r.client.QueryRow(ctx, query, id).Scan(&genre.Id, &genre.Name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("GenreRepo GetGenreByID: %v", err)
+	}
+
+	return &genre, nil
+}
+
+func (r *GenreRepo) GetAllGenres(ctx context.Context) ([]*entity.Genre, error) {
+	query := `SELECT id, name FROM genres ORDER BY name`
+
+	rows, err := r.client.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("GenreRepo GetAllGenres: %v", err)
+	}
+	defer rows.Close()
+
+	genres := make([]*entity.Genre, 0)
+	for rows.Next() {
+		var g entity.Genre
+
+		err = rows.Scan(&g.Id, &g.Name)
+		if err != nil {
+			return nil, fmt.Errorf("GenreRepo GetAllGenres: %v", err)
+		}
+
+		genres = append(genres, &g)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GenreRepo GetAllGenres: %v", err)
+	}
+
+	return genres, nil
+}
+
+func (r *GenreRepo) EditGenre(ctx context.Context, genre *entity.Genre) error {
+	query := `UPDATE genres SET name = $2 WHERE id = $1`
+
+	commandTag, err := r.client.Exec(ctx, query, genre.Id, genre.Name)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return repoerrs.ErrAlreadyExists
+		}
+		return fmt.Errorf("GenreRepo EditGenre: %v", err)
+	}
+	if commandTag.RowsAffected() != 1 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *GenreRepo) DeleteGenre(ctx context.Context, id int) error {
+	query := `DELETE FROM genres WHERE id = $1`
+
+	commandTag, err := r.client.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("GenreRepo DeleteGenre: %v", err)
+	}
+	if commandTag.RowsAffected() != 1 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
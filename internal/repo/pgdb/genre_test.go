@@ -0,0 +1,161 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo/repoerrs"
+)
+
+func TestGenreRepo_CreateGenre(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		genre *entity.Genre
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:   context.Background(),
+				genre: &entity.Genre{Name: "drama"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("INSERT INTO genres").
+					WithArgs(args.genre.Name).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			want:    1,
+			wantErr: nil,
+		},
+		{
+			name: "genre already exists",
+			args: args{
+				ctx:   context.Background(),
+				genre: &entity.Genre{Name: "drama"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("INSERT INTO genres").
+					WithArgs(args.genre.Name).
+					WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			want:    0,
+			wantErr: repoerrs.ErrAlreadyExists,
+		},
+		{
+			name: "unexpected error",
+			args: args{
+				ctx:   context.Background(),
+				genre: &entity.Genre{Name: "drama"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("INSERT INTO genres").
+					WithArgs(args.genre.Name).
+					WillReturnError(errors.New("some error"))
+			},
+			want:    0,
+			wantErr: errors.New("GenreRepo CreateGenre: some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			genreRepoMock := NewGenreRepo(poolMock)
+
+			got, err := genreRepoMock.CreateGenre(tc.args.ctx, tc.args.genre)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGenreRepo_EditGenre(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		genre *entity.Genre
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:   context.Background(),
+				genre: &entity.Genre{Id: 1, Name: "drama"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE genres SET name = \\$2 WHERE id = \\$1").
+					WithArgs(args.genre.Id, args.genre.Name).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "genre not found",
+			args: args{
+				ctx:   context.Background(),
+				genre: &entity.Genre{Id: 1, Name: "drama"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE genres").
+					WithArgs(args.genre.Id, args.genre.Name).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoerrs.ErrNotFound,
+		},
+		{
+			name: "genre already exists",
+			args: args{
+				ctx:   context.Background(),
+				genre: &entity.Genre{Id: 1, Name: "drama"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE genres").
+					WithArgs(args.genre.Id, args.genre.Name).
+					WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			wantErr: repoerrs.ErrAlreadyExists,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			genreRepoMock := NewGenreRepo(poolMock)
+
+			err := genreRepoMock.EditGenre(tc.args.ctx, tc.args.genre)
+			assert.Equal(t, tc.wantErr, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"vk-film-library/pkg/postgres"
+)
+
+type PermissionRepo struct {
+	client postgres.Client
+}
+
+func NewPermissionRepo(client postgres.Client) *PermissionRepo {
+	return &PermissionRepo{
+		client: client,
+	}
+}
+
+func (r *PermissionRepo) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM role_permissions WHERE role = $1 AND permission = $2)`
+	var exists bool
+
+	err := r.client.QueryRow(ctx, query, role, permission).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("PermissionRepo HasPermission: %v", err)
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,95 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"vk-film-library/internal/entity"
+)
+
+func TestPermissionRepo_HasPermission(t *testing.T) {
+	type args struct {
+		ctx        context.Context
+		role       string
+		permission string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         bool
+		wantErr      bool
+	}{
+		{
+			name: "granted",
+			args: args{
+				ctx:        context.Background(),
+				role:       entity.UserRoleAdmin,
+				permission: entity.PermFilmsWrite,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM role_permissions WHERE role = \\$1 AND permission = \\$2\\)").
+					WithArgs(args.role, args.permission).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "not granted",
+			args: args{
+				ctx:        context.Background(),
+				role:       entity.UserRoleUser,
+				permission: entity.PermFilmsWrite,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("FROM role_permissions").
+					WithArgs(args.role, args.permission).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "unexpected error",
+			args: args{
+				ctx:        context.Background(),
+				role:       entity.UserRoleUser,
+				permission: entity.PermFilmsRead,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("FROM role_permissions").
+					WithArgs(args.role, args.permission).
+					WillReturnError(errors.New("some error"))
+			},
+			want:    false,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			permissionRepoMock := NewPermissionRepo(poolMock)
+
+			got, err := permissionRepoMock.HasPermission(tc.args.ctx, tc.args.role, tc.args.permission)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
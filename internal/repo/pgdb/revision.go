@@ -0,0 +1,102 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo/repoerrs"
+	"vk-film-library/pkg/postgres"
+)
+
+type RevisionRepo struct {
+	client postgres.Client
+}
+
+func NewRevisionRepo(client postgres.Client) *RevisionRepo {
+	return &RevisionRepo{
+		client: client,
+	}
+}
+
+// CreateRevision stores the revision as the next one of its entity and
+// returns its number. The number is taken under an advisory lock of the entity
+// held until the end of the transaction, so that concurrent revisions of the
+// entity never get the same number. Called in the transaction of a change,
+// the lock is held until the change is committed.
+func (r *RevisionRepo) CreateRevision(ctx context.Context, revision *entity.Revision) (int, error) {
+	tx, err := conn(ctx, r.client).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("RevisionRepo CreateRevision: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1), $2)`, revision.EntityType, revision.EntityId)
+	if err != nil {
+		return 0, fmt.Errorf("RevisionRepo CreateRevision: %v", err)
+	}
+
+	query := `INSERT INTO revisions (entity_type, entity_id, number, user_id, username, data)
+		SELECT $1, $2, coalesce(max(number), 0) + 1, $3, $4, $5 FROM revisions WHERE entity_type = $1 AND entity_id = $2
+		RETURNING number`
+
+	var number int
+	err = tx.QueryRow(ctx, query, revision.EntityType, revision.EntityId, revision.UserId, revision.Username,
+		revision.Data).Scan(&number)
+	if err != nil {
+		return 0, fmt.Errorf("RevisionRepo CreateRevision: %v", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("RevisionRepo CreateRevision: %v", err)
+	}
+
+	return number, nil
+}
+
+// GetRevisions returns the revisions of the entity without their data, most recent first.
+func (r *RevisionRepo) GetRevisions(ctx context.Context, entityType string, entityId int) ([]*entity.Revision, error) {
+	query := `SELECT number, entity_type, entity_id, user_id, username, created_at FROM revisions
+		WHERE entity_type = $1 AND entity_id = $2 ORDER BY number DESC`
+
+	rows, err := conn(ctx, r.client).Query(ctx, query, entityType, entityId)
+	if err != nil {
+		return nil, fmt.Errorf("RevisionRepo GetRevisions: %v", err)
+	}
+	defer rows.Close()
+
+	revisions := make([]*entity.Revision, 0)
+	for rows.Next() {
+		var rev entity.Revision
+
+		err = rows.Scan(&rev.Number, &rev.EntityType, &rev.EntityId, &rev.UserId, &rev.Username, &rev.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("RevisionRepo GetRevisions: %v", err)
+		}
+
+		revisions = append(revisions, &rev)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("RevisionRepo GetRevisions: %v", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *RevisionRepo) GetRevision(ctx context.Context, entityType string, entityId, number int) (*entity.Revision, error) {
+	query := `SELECT number, entity_type, entity_id, user_id, username, created_at, data FROM revisions
+		WHERE entity_type = $1 AND entity_id = $2 AND number = $3`
+
+	var rev entity.Revision
+	err := conn(ctx, r.client).QueryRow(ctx, query, entityType, entityId, number).
+		Scan(&rev.Number, &rev.EntityType, &rev.EntityId, &rev.UserId, &rev.Username, &rev.CreatedAt, &rev.Data)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("RevisionRepo GetRevision: %v", err)
+	}
+
+	return &rev, nil
+}
@@ -0,0 +1,245 @@
+package pgdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo/repoerrs"
+)
+
+func TestRevisionRepo_CreateRevision(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		revision *entity.Revision
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx: context.Background(),
+				revision: &entity.Revision{
+					EntityType: entity.EntityFilm,
+					EntityId:   7,
+					UserId:     2,
+					Username:   "admin",
+					Data:       json.RawMessage(`{"name":"new"}`),
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectExec("SELECT pg_advisory_xact_lock\\(hashtext\\(\\$1\\), \\$2\\)").
+					WithArgs(entity.EntityFilm, 7).
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+				m.ExpectQuery("INSERT INTO revisions \\(entity_type, entity_id, number, user_id, username, data\\) "+
+					"SELECT \\$1, \\$2, coalesce\\(max\\(number\\), 0\\) \\+ 1, \\$3, \\$4, \\$5 FROM revisions").
+					WithArgs(entity.EntityFilm, 7, 2, "admin", args.revision.Data).
+					WillReturnRows(pgxmock.NewRows([]string{"number"}).AddRow(3))
+				m.ExpectCommit()
+			},
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name: "database error",
+			args: args{
+				ctx:      context.Background(),
+				revision: &entity.Revision{EntityType: entity.EntityActor, EntityId: 1, Data: json.RawMessage(`{}`)},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectExec("SELECT pg_advisory_xact_lock").
+					WithArgs(entity.EntityActor, 1).
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+				m.ExpectQuery("INSERT INTO revisions").
+					WithArgs(entity.EntityActor, 1, 0, "", args.revision.Data).
+					WillReturnError(fmt.Errorf("some error"))
+				m.ExpectRollback()
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			revisionRepoMock := NewRevisionRepo(poolMock)
+
+			got, err := revisionRepoMock.CreateRevision(tc.args.ctx, tc.args.revision)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// The lock of a revision created in the transaction of a change is held until
+// the change is committed, the revision itself only releases its savepoint.
+func TestRevisionRepo_CreateRevision_InTx(t *testing.T) {
+	poolMock, _ := pgxmock.NewPool()
+	defer poolMock.Close()
+
+	poolMock.ExpectBegin()
+	poolMock.ExpectQuery("SELECT id FROM films WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(7))
+	poolMock.ExpectBegin()
+	poolMock.ExpectExec("SELECT pg_advisory_xact_lock").
+		WithArgs(entity.EntityFilm, 7).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	poolMock.ExpectQuery("INSERT INTO revisions").
+		WithArgs(entity.EntityFilm, 7, 2, "admin", json.RawMessage(`{}`)).
+		WillReturnRows(pgxmock.NewRows([]string{"number"}).AddRow(2))
+	poolMock.ExpectCommit()
+	poolMock.ExpectCommit()
+
+	transactor := NewTransactor(poolMock)
+	filmRepo := NewFilmRepo(poolMock)
+	revisionRepo := NewRevisionRepo(poolMock)
+
+	var number int
+	err := transactor.WithTx(context.Background(), func(ctx context.Context) error {
+		err := filmRepo.LockFilm(ctx, 7)
+		if err != nil {
+			return err
+		}
+
+		number, err = revisionRepo.CreateRevision(ctx, &entity.Revision{
+			EntityType: entity.EntityFilm,
+			EntityId:   7,
+			UserId:     2,
+			Username:   "admin",
+			Data:       json.RawMessage(`{}`),
+		})
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, number)
+
+	assert.NoError(t, poolMock.ExpectationsWereMet())
+}
+
+func TestRevisionRepo_GetRevisions(t *testing.T) {
+	poolMock, _ := pgxmock.NewPool()
+	defer poolMock.Close()
+
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	rows := pgxmock.NewRows([]string{"number", "entity_type", "entity_id", "user_id", "username", "created_at"}).
+		AddRow(2, entity.EntityActor, 4, 2, "admin", createdAt).
+		AddRow(1, entity.EntityActor, 4, 0, "", createdAt)
+	poolMock.ExpectQuery("SELECT number, entity_type, entity_id, user_id, username, created_at FROM revisions "+
+		"WHERE entity_type = \\$1 AND entity_id = \\$2 ORDER BY number DESC").
+		WithArgs(entity.EntityActor, 4).
+		WillReturnRows(rows)
+
+	got, err := NewRevisionRepo(poolMock).GetRevisions(context.Background(), entity.EntityActor, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.Revision{
+		{Number: 2, EntityType: entity.EntityActor, EntityId: 4, UserId: 2, Username: "admin", CreatedAt: createdAt},
+		{Number: 1, EntityType: entity.EntityActor, EntityId: 4, CreatedAt: createdAt},
+	}, got)
+
+	assert.NoError(t, poolMock.ExpectationsWereMet())
+}
+
+func TestRevisionRepo_GetRevision(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		number int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"number", "entity_type", "entity_id", "user_id", "username", "created_at", "data"}
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         *entity.Revision
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:    context.Background(),
+				number: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows(columns).
+					AddRow(2, entity.EntityFilm, 7, 2, "admin", createdAt, json.RawMessage(`{"name":"new"}`))
+
+				m.ExpectQuery("SELECT number, entity_type, entity_id, user_id, username, created_at, data FROM revisions "+
+					"WHERE entity_type = \\$1 AND entity_id = \\$2 AND number = \\$3").
+					WithArgs(entity.EntityFilm, 7, args.number).
+					WillReturnRows(rows)
+			},
+			want: &entity.Revision{
+				Number:     2,
+				EntityType: entity.EntityFilm,
+				EntityId:   7,
+				UserId:     2,
+				Username:   "admin",
+				CreatedAt:  createdAt,
+				Data:       json.RawMessage(`{"name":"new"}`),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				ctx:    context.Background(),
+				number: 5,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("FROM revisions").
+					WithArgs(entity.EntityFilm, 7, args.number).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			want:    nil,
+			wantErr: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			revisionRepoMock := NewRevisionRepo(poolMock)
+
+			got, err := revisionRepoMock.GetRevision(tc.args.ctx, entity.EntityFilm, 7, tc.args.number)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
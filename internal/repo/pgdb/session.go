@@ -0,0 +1,126 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo/repoerrs"
+	"vk-film-library/pkg/postgres"
+)
+
+type SessionRepo struct {
+	client postgres.Client
+}
+
+func NewSessionRepo(client postgres.Client) *SessionRepo {
+	return &SessionRepo{
+		client: client,
+	}
+}
+
+// CreateSession stores the session with its first refresh token.
+func (r *SessionRepo) CreateSession(ctx context.Context, session *entity.Session, tokenHash string) (int, error) {
+	tx, err := r.client.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("SessionRepo CreateSession: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	query := `INSERT INTO sessions (user_id, expires_at) VALUES ($1, $2) RETURNING id`
+	err = tx.QueryRow(ctx, query, session.UserId, session.ExpiresAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("SessionRepo CreateSession: %v", err)
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO refresh_tokens (token_hash, session_id) VALUES ($1, $2)`, tokenHash, id)
+	if err != nil {
+		return 0, fmt.Errorf("SessionRepo CreateSession: %v", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("SessionRepo CreateSession: %v", err)
+	}
+
+	return id, nil
+}
+
+func (r *SessionRepo) GetSession(ctx context.Context, id int) (*entity.Session, error) {
+	query := `SELECT id, user_id, created_at, expires_at, revoked_at FROM sessions WHERE id = $1`
+	var session entity.Session
+
+	err := r.client.QueryRow(ctx, query, id).
+		Scan(&session.Id, &session.UserId, &session.CreatedAt, &session.ExpiresAt, &session.RevokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("SessionRepo GetSession: %v", err)
+	}
+
+	return &session, nil
+}
+
+func (r *SessionRepo) GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	query := `SELECT s.id, s.user_id, s.created_at, s.expires_at, s.revoked_at, u.username, u.role, rt.used_at
+		FROM refresh_tokens rt
+		JOIN sessions s ON s.id = rt.session_id
+		JOIN users u ON u.id = s.user_id
+		WHERE rt.token_hash = $1`
+	var token entity.RefreshToken
+
+	err := r.client.QueryRow(ctx, query, tokenHash).Scan(&token.Session.Id, &token.Session.UserId, &token.Session.CreatedAt,
+		&token.Session.ExpiresAt, &token.Session.RevokedAt, &token.Username, &token.Role, &token.UsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("SessionRepo GetRefreshToken: %v", err)
+	}
+
+	return &token, nil
+}
+
+// RotateRefreshToken marks the refresh token as used and stores the next one of
+// the session. It returns repoerrs.ErrNotFound if the token is already used.
+func (r *SessionRepo) RotateRefreshToken(ctx context.Context, sessionId int, tokenHash, nextTokenHash string) error {
+	tx, err := r.client.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("SessionRepo RotateRefreshToken: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE refresh_tokens SET used_at = now() WHERE token_hash = $1 AND session_id = $2 AND used_at IS NULL`
+	tag, err := tx.Exec(ctx, query, tokenHash, sessionId)
+	if err != nil {
+		return fmt.Errorf("SessionRepo RotateRefreshToken: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrNotFound
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO refresh_tokens (token_hash, session_id) VALUES ($1, $2)`, nextTokenHash, sessionId)
+	if err != nil {
+		return fmt.Errorf("SessionRepo RotateRefreshToken: %v", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("SessionRepo RotateRefreshToken: %v", err)
+	}
+
+	return nil
+}
+
+// RevokeSession revokes the session, a revoked session stays revoked.
+func (r *SessionRepo) RevokeSession(ctx context.Context, id int) error {
+	query := `UPDATE sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`
+
+	_, err := r.client.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("SessionRepo RevokeSession: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo/repoerrs"
+)
+
+func TestSessionRepo_CreateSession(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		session   *entity.Session
+		tokenHash string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	expiresAt := time.Date(2024, time.April, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:       context.Background(),
+				session:   &entity.Session{UserId: 1, ExpiresAt: expiresAt},
+				tokenHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectQuery("INSERT INTO sessions \\(user_id, expires_at\\) VALUES \\(\\$1, \\$2\\) RETURNING id").
+					WithArgs(args.session.UserId, args.session.ExpiresAt).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(3))
+				m.ExpectExec("INSERT INTO refresh_tokens \\(token_hash, session_id\\) VALUES \\(\\$1, \\$2\\)").
+					WithArgs(args.tokenHash, 3).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectCommit()
+			},
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name: "unexpected error",
+			args: args{
+				ctx:       context.Background(),
+				session:   &entity.Session{UserId: 1, ExpiresAt: expiresAt},
+				tokenHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectQuery("INSERT INTO sessions").
+					WithArgs(args.session.UserId, args.session.ExpiresAt).
+					WillReturnError(errors.New("some error"))
+				m.ExpectRollback()
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			sessionRepoMock := NewSessionRepo(poolMock)
+
+			got, err := sessionRepoMock.CreateSession(tc.args.ctx, tc.args.session, tc.args.tokenHash)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSessionRepo_GetRefreshToken(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		tokenHash string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(720 * time.Hour)
+	usedAt := createdAt.Add(time.Hour)
+	columns := []string{"id", "user_id", "created_at", "expires_at", "revoked_at", "username", "role", "used_at"}
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         *entity.RefreshToken
+		wantErr      error
+	}{
+		{
+			name: "used token",
+			args: args{
+				ctx:       context.Background(),
+				tokenHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows(columns).
+					AddRow(3, 1, createdAt, expiresAt, (*time.Time)(nil), "test_user", "user", &usedAt)
+
+				m.ExpectQuery("FROM refresh_tokens rt JOIN sessions s ON s.id = rt.session_id JOIN users u ON u.id = s.user_id " +
+					"WHERE rt.token_hash = \\$1").
+					WithArgs(args.tokenHash).
+					WillReturnRows(rows)
+			},
+			want: &entity.RefreshToken{
+				Session: entity.Session{
+					Id:        3,
+					UserId:    1,
+					CreatedAt: createdAt,
+					ExpiresAt: expiresAt,
+				},
+				Username: "test_user",
+				Role:     "user",
+				UsedAt:   &usedAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "token not found",
+			args: args{
+				ctx:       context.Background(),
+				tokenHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("FROM refresh_tokens").
+					WithArgs(args.tokenHash).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			want:    nil,
+			wantErr: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			sessionRepoMock := NewSessionRepo(poolMock)
+
+			got, err := sessionRepoMock.GetRefreshToken(tc.args.ctx, tc.args.tokenHash)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSessionRepo_RotateRefreshToken(t *testing.T) {
+	type args struct {
+		ctx           context.Context
+		sessionId     int
+		tokenHash     string
+		nextTokenHash string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:           context.Background(),
+				sessionId:     3,
+				tokenHash:     "hash",
+				nextTokenHash: "next_hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE refresh_tokens SET used_at = now\\(\\) "+
+					"WHERE token_hash = \\$1 AND session_id = \\$2 AND used_at IS NULL").
+					WithArgs(args.tokenHash, args.sessionId).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				m.ExpectExec("INSERT INTO refresh_tokens").
+					WithArgs(args.nextTokenHash, args.sessionId).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "token already used",
+			args: args{
+				ctx:           context.Background(),
+				sessionId:     3,
+				tokenHash:     "hash",
+				nextTokenHash: "next_hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE refresh_tokens SET used_at").
+					WithArgs(args.tokenHash, args.sessionId).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				m.ExpectRollback()
+			},
+			wantErr: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			sessionRepoMock := NewSessionRepo(poolMock)
+
+			err := sessionRepoMock.RotateRefreshToken(tc.args.ctx, tc.args.sessionId, tc.args.tokenHash, tc.args.nextTokenHash)
+			assert.Equal(t, tc.wantErr, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
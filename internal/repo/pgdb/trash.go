@@ -0,0 +1,37 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"vk-film-library/internal/entity"
+	"vk-film-library/pkg/postgres"
+)
+
+// getTrash returns the deleted rows of the table, recently deleted first.
+// The table must have the deleted_at column.
+func getTrash(ctx context.Context, client postgres.Client, table string) ([]*entity.TrashItem, error) {
+	query := fmt.Sprintf(`SELECT id, name, deleted_at FROM %s WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id`, table)
+
+	rows, err := client.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*entity.TrashItem, 0)
+	for rows.Next() {
+		var item entity.TrashItem
+
+		err = rows.Scan(&item.Id, &item.Name, &item.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, &item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,62 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"vk-film-library/internal/entity"
+	"vk-film-library/pkg/postgres"
+)
+
+// similarityThreshold is the lowest pg_trgm word similarity of a fuzzy match.
+// The default threshold of 0.6 misses most typos in short names.
+const similarityThreshold = 0.3
+
+// findByName looks for rows of the table whose name contains the given name or
+// is similar to it, exact matches go first, then the most similar ones.
+// Deleted rows are skipped.
+// The table must have the gin_trgm_ops index on name.
+func findByName(ctx context.Context, client postgres.Client, table, name string, limit int) ([]*entity.NameMatch, error) {
+	tx, err := client.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// the <% operator uses the threshold from the settings and, unlike the
+	// word_similarity function, can be served by the trigram index
+	query := `SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`
+	_, err = tx.Exec(ctx, query, fmt.Sprint(similarityThreshold))
+	if err != nil {
+		return nil, err
+	}
+
+	query = fmt.Sprintf(`SELECT id, name, word_similarity($1, name) AS similarity, name ILIKE $2 AS exact
+		FROM %s
+		WHERE deleted_at IS NULL AND (name ILIKE $2 OR $1 <%% name)
+		ORDER BY exact DESC, similarity DESC, id
+		LIMIT $3`, table)
+
+	rows, err := tx.Query(ctx, query, name, containsPattern(name), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := make([]*entity.NameMatch, 0)
+	for rows.Next() {
+		var m entity.NameMatch
+
+		err = rows.Scan(&m.Id, &m.Name, &m.Similarity, &m.Exact)
+		if err != nil {
+			return nil, err
+		}
+
+		matches = append(matches, &m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,66 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"vk-film-library/pkg/postgres"
+)
+
+// querier is implemented by both postgres.Client and pgx.Tx. Begin of a pgx.Tx
+// starts a nested transaction with a savepoint.
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type txKey struct{}
+
+// Transactor runs functions in a transaction. The repositories called with the
+// context passed to a function take part in its transaction.
+type Transactor struct {
+	client postgres.Client
+}
+
+func NewTransactor(client postgres.Client) *Transactor {
+	return &Transactor{
+		client: client,
+	}
+}
+
+// WithTx calls fn in a transaction that is committed if fn returns nil and
+// rolled back otherwise. Called inside a transaction, it joins that one.
+func (t *Transactor) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.client.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("Transactor WithTx: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("Transactor WithTx: %v", err)
+	}
+
+	return nil
+}
+
+// conn returns the transaction of ctx started by WithTx or client if there is none.
+func conn(ctx context.Context, client postgres.Client) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return client
+}
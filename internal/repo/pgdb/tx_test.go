@@ -0,0 +1,95 @@
+package pgdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"vk-film-library/internal/entity"
+)
+
+func TestTransactor_WithTx(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	someErr := errors.New("some error")
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		fnErr        error
+		wantErr      error
+	}{
+		{
+			name: "commit",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectExec("INSERT INTO audit_log").
+					WithArgs(0, "", entity.AuditCreate, entity.EntityFilm, 1, json.RawMessage(nil), json.RawMessage(nil)).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectCommit()
+			},
+			fnErr:   nil,
+			wantErr: nil,
+		},
+		{
+			name: "rollback on error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectExec("INSERT INTO audit_log").
+					WithArgs(0, "", entity.AuditCreate, entity.EntityFilm, 1, json.RawMessage(nil), json.RawMessage(nil)).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectRollback()
+			},
+			fnErr:   someErr,
+			wantErr: someErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			transactor := NewTransactor(poolMock)
+			auditRepo := NewAuditRepo(poolMock)
+
+			err := transactor.WithTx(context.Background(), func(ctx context.Context) error {
+				err := auditRepo.CreateAuditEntry(ctx, &entity.AuditEntry{Action: entity.AuditCreate, EntityType: entity.EntityFilm, EntityId: 1})
+				if err != nil {
+					return err
+				}
+				return tc.fnErr
+			})
+			assert.Equal(t, tc.wantErr, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTransactor_WithTx_Nested(t *testing.T) {
+	poolMock, _ := pgxmock.NewPool()
+	defer poolMock.Close()
+
+	poolMock.ExpectBegin()
+	poolMock.ExpectQuery("SELECT id FROM films WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	poolMock.ExpectCommit()
+
+	transactor := NewTransactor(poolMock)
+	filmRepo := NewFilmRepo(poolMock)
+
+	err := transactor.WithTx(context.Background(), func(ctx context.Context) error {
+		return transactor.WithTx(ctx, func(ctx context.Context) error {
+			return filmRepo.LockFilm(ctx, 1)
+		})
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, poolMock.ExpectationsWereMet())
+}
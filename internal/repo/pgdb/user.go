@@ -11,6 +11,10 @@ import (
 	"vk-film-library/pkg/postgres"
 )
 
+// adminBootstrapLockId is the key of the advisory lock that serializes the
+// admin bootstrap of concurrently starting instances.
+const adminBootstrapLockId = 7_249_316
+
 type UserRepo struct {
 	client postgres.Client
 }
@@ -25,7 +29,7 @@ func (r *UserRepo) CreateUser(ctx context.Context, user *entity.User) (int, erro
 	query := `INSERT INTO users (username, password, role) VALUES ($1, $2, $3) RETURNING id`
 	var id int
 
-	err := r.client.QueryRow(ctx, query, user.Username, user.Password, user.Role).Scan(&id)
+	err := conn(ctx, r.client).QueryRow(ctx, query, user.Username, user.Password, user.Role).Scan(&id)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if ok := errors.As(err, &pgErr); ok {
@@ -39,17 +43,70 @@ func (r *UserRepo) CreateUser(ctx context.Context, user *entity.User) (int, erro
 	return id, nil
 }
 
-func (r *UserRepo) GetUserByUsernameAndPassword(ctx context.Context, username, password string) (*entity.User, error) {
+func (r *UserRepo) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
 	var user entity.User
-	query := `SELECT id, username, password, role FROM users WHERE username=$1 AND password=$2`
+	query := `SELECT id, username, password, role FROM users WHERE username=$1`
 
-	err := r.client.QueryRow(ctx, query, username, password).Scan(&user.Id, &user.Username, &user.Password, &user.Role)
+	err := conn(ctx, r.client).QueryRow(ctx, query, username).Scan(&user.Id, &user.Username, &user.Password, &user.Role)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return &entity.User{}, repoerrs.ErrNotFound
 		}
-		return &entity.User{}, fmt.Errorf("UserRepo GetUserByUsernameAndPassword: %v", err)
+		return &entity.User{}, fmt.Errorf("UserRepo GetUserByUsername: %v", err)
 	}
 
 	return &user, nil
 }
+
+func (r *UserRepo) UpdateUserPassword(ctx context.Context, id int, password string) error {
+	query := `UPDATE users SET password=$2 WHERE id=$1`
+
+	tag, err := conn(ctx, r.client).Exec(ctx, query, id, password)
+	if err != nil {
+		return fmt.Errorf("UserRepo UpdateUserPassword: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateUserRole returns repoerrs.ErrNotFound if there is no user with the id
+// or the user has the role already.
+func (r *UserRepo) UpdateUserRole(ctx context.Context, id int, role string) error {
+	query := `UPDATE users SET role=$2 WHERE id=$1 AND role<>$2`
+
+	tag, err := conn(ctx, r.client).Exec(ctx, query, id, role)
+	if err != nil {
+		return fmt.Errorf("UserRepo UpdateUserRole: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) HasUserWithRole(ctx context.Context, role string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM users WHERE role=$1)`
+	var exists bool
+
+	err := conn(ctx, r.client).QueryRow(ctx, query, role).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("UserRepo HasUserWithRole: %v", err)
+	}
+
+	return exists, nil
+}
+
+// LockAdminBootstrap takes an advisory lock until the end of the transaction
+// of ctx, so that concurrent bootstraps check for an admin one at a time.
+func (r *UserRepo) LockAdminBootstrap(ctx context.Context) error {
+	_, err := conn(ctx, r.client).Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, adminBootstrapLockId)
+	if err != nil {
+		return fmt.Errorf("UserRepo LockAdminBootstrap: %v", err)
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo/repoerrs"
 )
 
 func TestUserRepo_CreateUser(t *testing.T) {
@@ -110,11 +111,10 @@ func TestUserRepo_CreateUser(t *testing.T) {
 	}
 }
 
-func TestUserRepo_GetUserByUsernameAndPassword(t *testing.T) {
+func TestUserRepo_GetUserByUsername(t *testing.T) {
 	type args struct {
 		ctx      context.Context
 		username string
-		password string
 	}
 
 	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
@@ -131,20 +131,19 @@ func TestUserRepo_GetUserByUsernameAndPassword(t *testing.T) {
 			args: args{
 				ctx:      context.Background(),
 				username: "test_user",
-				password: "Qwerty1!",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
 				rows := pgxmock.NewRows([]string{"id", "username", "password", "role"}).
-					AddRow(1, args.username, args.password, "user")
+					AddRow(1, args.username, "$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$a2V5", "user")
 
 				m.ExpectQuery("SELECT id, username, password, role FROM users").
-					WithArgs(args.username, args.password).
+					WithArgs(args.username).
 					WillReturnRows(rows)
 			},
 			want: &entity.User{
 				Id:       1,
 				Username: "test_user",
-				Password: "Qwerty1!",
+				Password: "$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$a2V5",
 				Role:     "user",
 			},
 			wantErr: false,
@@ -154,11 +153,10 @@ func TestUserRepo_GetUserByUsernameAndPassword(t *testing.T) {
 			args: args{
 				ctx:      context.Background(),
 				username: "test_user",
-				password: "Qwerty1!",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
 				m.ExpectQuery("SELECT id, username, password, role FROM users").
-					WithArgs(args.username, args.password).
+					WithArgs(args.username).
 					WillReturnError(pgx.ErrNoRows)
 			},
 			want:    &entity.User{},
@@ -169,11 +167,10 @@ func TestUserRepo_GetUserByUsernameAndPassword(t *testing.T) {
 			args: args{
 				ctx:      context.Background(),
 				username: "test_user",
-				password: "Qwerty1!",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
 				m.ExpectQuery("SELECT id, username, password, role FROM users").
-					WithArgs(args.username, args.password).
+					WithArgs(args.username).
 					WillReturnError(errors.New("some error"))
 			},
 			want:    &entity.User{},
@@ -190,7 +187,7 @@ func TestUserRepo_GetUserByUsernameAndPassword(t *testing.T) {
 			postgresMock := poolMock
 			userRepoMock := NewUserRepo(postgresMock)
 
-			got, err := userRepoMock.GetUserByUsernameAndPassword(tc.args.ctx, tc.args.username, tc.args.password)
+			got, err := userRepoMock.GetUserByUsername(tc.args.ctx, tc.args.username)
 			if tc.wantErr {
 				assert.Error(t, err)
 				return
@@ -203,3 +200,184 @@ func TestUserRepo_GetUserByUsernameAndPassword(t *testing.T) {
 		})
 	}
 }
+
+func TestUserRepo_UpdateUserPassword(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		id       int
+		password string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:      context.Background(),
+				id:       1,
+				password: "$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$a2V5",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE users SET password=\\$2 WHERE id=\\$1").
+					WithArgs(args.id, args.password).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "user not found",
+			args: args{
+				ctx:      context.Background(),
+				id:       2,
+				password: "$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$a2V5",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE users SET password").
+					WithArgs(args.id, args.password).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			userRepoMock := NewUserRepo(poolMock)
+
+			err := userRepoMock.UpdateUserPassword(tc.args.ctx, tc.args.id, tc.args.password)
+			assert.Equal(t, tc.wantErr, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserRepo_UpdateUserRole(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users SET role=\\$2 WHERE id=\\$1 AND role<>\\$2").
+					WithArgs(1, entity.UserRoleAdmin).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "role already set",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE users SET role=\\$2 WHERE id=\\$1 AND role<>\\$2").
+					WithArgs(1, entity.UserRoleAdmin).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			err := NewUserRepo(poolMock).UpdateUserRole(context.Background(), 1, entity.UserRoleAdmin)
+			assert.Equal(t, tc.wantErr, err)
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserRepo_HasUserWithRole(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         bool
+		wantErr      bool
+	}{
+		{
+			name: "admin exists",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM users WHERE role=\\$1\\)").
+					WithArgs(entity.UserRoleAdmin).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "no admin",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT EXISTS").
+					WithArgs(entity.UserRoleAdmin).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT EXISTS").
+					WithArgs(entity.UserRoleAdmin).
+					WillReturnError(errors.New("some error"))
+			},
+			want:    false,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			userRepoMock := NewUserRepo(poolMock)
+
+			got, err := userRepoMock.HasUserWithRole(context.Background(), entity.UserRoleAdmin)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserRepo_LockAdminBootstrap(t *testing.T) {
+	poolMock, _ := pgxmock.NewPool()
+	defer poolMock.Close()
+
+	poolMock.ExpectExec("SELECT pg_advisory_xact_lock\\(\\$1\\)").
+		WithArgs(adminBootstrapLockId).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+
+	err := NewUserRepo(poolMock).LockAdminBootstrap(context.Background())
+	assert.NoError(t, err)
+
+	assert.NoError(t, poolMock.ExpectationsWereMet())
+}
@@ -7,37 +7,102 @@ import (
 	"vk-film-library/pkg/postgres"
 )
 
+// Transactor runs fn in a transaction, the repositories called with the
+// context passed to fn take part in it.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type UserRepo interface {
 	CreateUser(ctx context.Context, user *entity.User) (int, error)
-	GetUserByUsernameAndPassword(ctx context.Context, username, password string) (*entity.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
+	UpdateUserPassword(ctx context.Context, id int, password string) error
+	UpdateUserRole(ctx context.Context, id int, role string) error
+	HasUserWithRole(ctx context.Context, role string) (bool, error)
+	LockAdminBootstrap(ctx context.Context) error
+}
+
+type SessionRepo interface {
+	CreateSession(ctx context.Context, session *entity.Session, tokenHash string) (int, error)
+	GetSession(ctx context.Context, id int) (*entity.Session, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, sessionId int, tokenHash, nextTokenHash string) error
+	RevokeSession(ctx context.Context, id int) error
+}
+
+type PermissionRepo interface {
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
 }
 
 type ActorRepo interface {
 	CreateActor(ctx context.Context, actor *entity.Actor) (int, error)
-	GetAllActors(ctx context.Context) ([]*entity.Actor, error)
-	EditActor(ctx context.Context, actor *entity.Actor) error
+	GetActorByID(ctx context.Context, id int) (*entity.Actor, error)
+	LockActor(ctx context.Context, id int) error
+	GetAllActors(ctx context.Context, page *entity.PageInput) ([]*entity.Actor, string, error)
+	FindActorsByName(ctx context.Context, name string, limit int) ([]*entity.NameMatch, error)
+	EditActor(ctx context.Context, input *entity.ActorEditInput) error
 	DeleteActor(ctx context.Context, id int) error
+	GetDeletedActors(ctx context.Context) ([]*entity.TrashItem, error)
+	RestoreActor(ctx context.Context, id int) error
+	PurgeActor(ctx context.Context, id int) error
 }
 
 type FilmRepo interface {
 	CreateFilm(ctx context.Context, film *entity.Film) (int, error)
-	GetSortFilms(ctx context.Context, sort string) ([]*entity.Film, error)
-	GetFilmsByName(ctx context.Context, namePart string) ([]*entity.Film, error)
-	GetFilmsByActor(ctx context.Context, namePart string) ([]*entity.Film, error)
-	//EditFilmByName(ctx context.Context, film *entity.Film) error
+	GetFilmByID(ctx context.Context, id int) (*entity.Film, error)
+	LockFilm(ctx context.Context, id int) error
+	GetFilms(ctx context.Context, filter *entity.FilmFilter, page *entity.PageInput) ([]*entity.Film, string, error)
+	CountFilmGenres(ctx context.Context, filter *entity.FilmFilter) ([]*entity.GenreCount, error)
+	SearchFilms(ctx context.Context, input *entity.FilmSearchInput) ([]*entity.FilmSearchResult, error)
+	FindFilmsByName(ctx context.Context, name string, limit int) ([]*entity.NameMatch, error)
+	EditFilm(ctx context.Context, id int, input *entity.FilmEditInput) error
 	DeleteFilm(ctx context.Context, id int) error
+	GetDeletedFilms(ctx context.Context) ([]*entity.TrashItem, error)
+	RestoreFilm(ctx context.Context, id int) error
+	PurgeFilm(ctx context.Context, id int) error
+}
+
+type GenreRepo interface {
+	CreateGenre(ctx context.Context, genre *entity.Genre) (int, error)
+	GetGenreByID(ctx context.Context, id int) (*entity.Genre, error)
+	GetAllGenres(ctx context.Context) ([]*entity.Genre, error)
+	EditGenre(ctx context.Context, genre *entity.Genre) error
+	DeleteGenre(ctx context.Context, id int) error
+}
+
+type AuditRepo interface {
+	CreateAuditEntry(ctx context.Context, entry *entity.AuditEntry) error
+	GetAuditEntries(ctx context.Context, filter *entity.AuditFilter, page *entity.PageInput) ([]*entity.AuditEntry, string, error)
+}
+
+type RevisionRepo interface {
+	CreateRevision(ctx context.Context, revision *entity.Revision) (int, error)
+	GetRevisions(ctx context.Context, entityType string, entityId int) ([]*entity.Revision, error)
+	GetRevision(ctx context.Context, entityType string, entityId, number int) (*entity.Revision, error)
 }
 
 type Repositories struct {
+	Transactor
 	UserRepo
+	SessionRepo
+	PermissionRepo
 	ActorRepo
 	FilmRepo
+	GenreRepo
+	AuditRepo
+	RevisionRepo
 }
 
 func NewRepositories(client postgres.Client) *Repositories {
 	return &Repositories{
-		UserRepo:  pgdb.NewUserRepo(client),
-		ActorRepo: pgdb.NewActorRepo(client),
-		FilmRepo:  pgdb.NewFilmRepo(client),
+		Transactor:     pgdb.NewTransactor(client),
+		UserRepo:       pgdb.NewUserRepo(client),
+		SessionRepo:    pgdb.NewSessionRepo(client),
+		PermissionRepo: pgdb.NewPermissionRepo(client),
+		ActorRepo:      pgdb.NewActorRepo(client),
+		FilmRepo:       pgdb.NewFilmRepo(client),
+		GenreRepo:      pgdb.NewGenreRepo(client),
+		AuditRepo:      pgdb.NewAuditRepo(client),
+		RevisionRepo:   pgdb.NewRevisionRepo(client),
 	}
 }
@@ -1,8 +1,62 @@
 package repoerrs
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 var (
 	ErrNotFound      = fmt.Errorf("not found")
 	ErrAlreadyExists = fmt.Errorf("already exists")
+	ErrInvalidCursor = fmt.Errorf("invalid cursor")
 )
+
+// ActorsNotFoundError lists the names and the ids of a film cast and crew
+// that match no actor.
+type ActorsNotFoundError struct {
+	Names []string
+	Ids   []int
+}
+
+func (e *ActorsNotFoundError) Error() string {
+	return fmt.Sprintf("actors not found: %s", joinActors(e.Names, e.Ids))
+}
+
+// AmbiguousActorsError lists the names of a film cast and crew that match
+// more than one actor.
+type AmbiguousActorsError struct {
+	Names []string
+}
+
+func (e *AmbiguousActorsError) Error() string {
+	return fmt.Sprintf("ambiguous actor names: %s", strings.Join(e.Names, ", "))
+}
+
+// GenresNotFoundError lists the genre ids of a film that match no genre.
+type GenresNotFoundError struct {
+	Ids []int
+}
+
+func (e *GenresNotFoundError) Error() string {
+	return fmt.Sprintf("genres not found: %s", joinIds(e.Ids))
+}
+
+func joinIds(ids []int) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// joinActors lists actor names and ids, the ids are marked as such.
+func joinActors(names []string, ids []int) string {
+	parts := append([]string(nil), names...)
+	for _, id := range ids {
+		parts = append(parts, "id "+strconv.Itoa(id))
+	}
+
+	return strings.Join(parts, ", ")
+}
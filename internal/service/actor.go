@@ -2,55 +2,242 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"time"
 	"vk-film-library/internal/entity"
 	"vk-film-library/internal/repo"
 	"vk-film-library/internal/repo/repoerrs"
 )
 
 type ActorService struct {
-	repo repo.ActorRepo
+	transactor   repo.Transactor
+	repo         repo.ActorRepo
+	auditRepo    repo.AuditRepo
+	revisionRepo repo.RevisionRepo
 }
 
-func NewActorService(repo repo.ActorRepo) *ActorService {
+func NewActorService(transactor repo.Transactor, repo repo.ActorRepo, auditRepo repo.AuditRepo, revisionRepo repo.RevisionRepo) *ActorService {
 	return &ActorService{
-		repo: repo,
+		transactor:   transactor,
+		repo:         repo,
+		auditRepo:    auditRepo,
+		revisionRepo: revisionRepo,
 	}
 }
 
 func (a *ActorService) CreateActor(ctx context.Context, input *entity.ActorCreateInput) (int, error) {
+	err := input.Validate()
+	if err != nil {
+		return 0, err
+	}
+
 	actor := &entity.Actor{
 		Name:     input.Name,
 		Gender:   input.Gender,
-		Birthday: input.Birthday,
+		Birthday: time.Time(input.Birthday),
 	}
 
-	return a.repo.CreateActor(ctx, actor)
-}
+	var id int
+	err = a.transactor.WithTx(ctx, func(ctx context.Context) error {
+		id, err = a.repo.CreateActor(ctx, actor)
+		if err != nil {
+			return err
+		}
 
-func (a *ActorService) GetAllActors(ctx context.Context) ([]*entity.Actor, error) {
-	return a.repo.GetAllActors(ctx)
+		after, err := a.repo.GetActorByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, a.auditRepo, entity.AuditCreate, entity.EntityActor, id, nil, after)
+		if err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, a.revisionRepo, entity.EntityActor, id, nil, entity.NewActorSnapshot(after))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
 }
 
-func (a *ActorService) EditActor(ctx context.Context, input *entity.Actor) error {
-	err := a.repo.EditActor(ctx, input)
+func (a *ActorService) GetActorByID(ctx context.Context, id int) (*entity.Actor, error) {
+	actor, err := a.repo.GetActorByID(ctx, id)
 	if err != nil {
 		if err == repoerrs.ErrNotFound {
-			return ErrActorNotFound
+			return nil, ErrActorNotFound
 		}
+		return nil, err
+	}
+
+	return actor, nil
+}
+
+func (a *ActorService) GetAllActors(ctx context.Context, page *entity.PageInput) ([]*entity.Actor, string, error) {
+	err := preparePage(page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	actors, nextCursor, err := a.repo.GetAllActors(ctx, page)
+	if err != nil {
+		if err == repoerrs.ErrInvalidCursor {
+			return nil, "", ErrInvalidCursor
+		}
+		return nil, "", err
+	}
+
+	return actors, nextCursor, nil
+}
+
+func (a *ActorService) FindActors(ctx context.Context, name string) (*entity.NameSearchResult, error) {
+	if name == "" {
+		return nil, ErrEmptyName
+	}
+
+	matches, err := a.repo.FindActorsByName(ctx, name, nameSearchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewNameSearchResult(matches), nil
+}
+
+func (a *ActorService) EditActor(ctx context.Context, input *entity.ActorEditInput) error {
+	return a.editActor(ctx, input, entity.AuditEdit)
+}
+
+// editActor changes an actor and records the change with the audit action in
+// the same transaction.
+func (a *ActorService) editActor(ctx context.Context, input *entity.ActorEditInput, action string) error {
+	err := input.Validate()
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return a.transactor.WithTx(ctx, func(ctx context.Context) error {
+		before, err := a.lockActor(ctx, input.Id)
+		if err != nil {
+			return err
+		}
+
+		err = a.repo.EditActor(ctx, input)
+		if err != nil {
+			if err == repoerrs.ErrNotFound {
+				return ErrActorNotFound
+			}
+			return err
+		}
+
+		after, err := a.repo.GetActorByID(ctx, input.Id)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, a.auditRepo, action, entity.EntityActor, input.Id, before, after)
+		if err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, a.revisionRepo, entity.EntityActor, input.Id, entity.NewActorSnapshot(before), entity.NewActorSnapshot(after))
+	})
 }
 
-func (a *ActorService) DeleteActor(ctx context.Context, id int) error {
-	err := a.repo.DeleteActor(ctx, id)
+// lockActor locks an actor until the end of the transaction of ctx and returns
+// it, so that the state before a change is not changed concurrently.
+func (a *ActorService) lockActor(ctx context.Context, id int) (*entity.Actor, error) {
+	err := a.repo.LockActor(ctx, id)
 	if err != nil {
 		if err == repoerrs.ErrNotFound {
-			return ErrActorNotFound
+			return nil, ErrActorNotFound
 		}
+		return nil, err
+	}
+
+	return a.repo.GetActorByID(ctx, id)
+}
+
+func (a *ActorService) DeleteActor(ctx context.Context, id int) error {
+	return a.transactor.WithTx(ctx, func(ctx context.Context) error {
+		before, err := a.lockActor(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = a.repo.DeleteActor(ctx, id)
+		if err != nil {
+			if err == repoerrs.ErrNotFound {
+				return ErrActorNotFound
+			}
+			return err
+		}
+
+		return recordAudit(ctx, a.auditRepo, entity.AuditDelete, entity.EntityActor, id, before, nil)
+	})
+}
+
+func (a *ActorService) GetDeletedActors(ctx context.Context) ([]*entity.TrashItem, error) {
+	return a.repo.GetDeletedActors(ctx)
+}
+
+func (a *ActorService) RestoreActor(ctx context.Context, id int) error {
+	return a.transactor.WithTx(ctx, func(ctx context.Context) error {
+		err := a.repo.RestoreActor(ctx, id)
+		if err != nil {
+			if err == repoerrs.ErrNotFound {
+				return ErrActorNotInTrash
+			}
+			return err
+		}
+
+		after, err := a.repo.GetActorByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, a.auditRepo, entity.AuditRestore, entity.EntityActor, id, nil, after)
+	})
+}
+
+// PurgeActor permanently deletes an actor from the trash. The last snapshot of
+// the actor is the one recorded when it was deleted.
+func (a *ActorService) PurgeActor(ctx context.Context, id int) error {
+	return a.transactor.WithTx(ctx, func(ctx context.Context) error {
+		err := a.repo.PurgeActor(ctx, id)
+		if err != nil {
+			if err == repoerrs.ErrNotFound {
+				return ErrActorNotInTrash
+			}
+			return err
+		}
+
+		return recordAudit(ctx, a.auditRepo, entity.AuditPurge, entity.EntityActor, id, nil, nil)
+	})
+}
+
+func (a *ActorService) GetActorRevisions(ctx context.Context, id int) ([]*entity.Revision, error) {
+	return a.revisionRepo.GetRevisions(ctx, entity.EntityActor, id)
+}
+
+func (a *ActorService) DiffActorRevisions(ctx context.Context, id, from, to int) (*entity.RevisionDiff, error) {
+	return diffRevisions(ctx, a.revisionRepo, entity.EntityActor, id, from, to)
+}
+
+// RevertActor brings an actor back to the state of one of its revisions. The
+// state is recorded as a new revision, so a revert can be reverted as well.
+func (a *ActorService) RevertActor(ctx context.Context, id, number int) error {
+	revision, err := getRevision(ctx, a.revisionRepo, entity.EntityActor, id, number)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	var snapshot entity.ActorSnapshot
+	err = json.Unmarshal(revision.Data, &snapshot)
+	if err != nil {
+		return fmt.Errorf("ActorService RevertActor: %v", err)
+	}
+
+	return a.editActor(ctx, snapshot.EditInput(id), entity.AuditRevert)
 }
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo"
+	"vk-film-library/internal/repo/repoerrs"
+)
+
+type AuditService struct {
+	repo repo.AuditRepo
+}
+
+func NewAuditService(repo repo.AuditRepo) *AuditService {
+	return &AuditService{
+		repo: repo,
+	}
+}
+
+func (s *AuditService) GetAuditLog(ctx context.Context, filter *entity.AuditFilter, page *entity.PageInput) ([]*entity.AuditEntry, string, error) {
+	err := filter.Validate()
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidAuditFilter, err)
+	}
+
+	err = preparePage(page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	entries, nextCursor, err := s.repo.GetAuditEntries(ctx, filter, page)
+	if err != nil {
+		if err == repoerrs.ErrInvalidCursor {
+			return nil, "", ErrInvalidCursor
+		}
+		return nil, "", err
+	}
+
+	return entries, nextCursor, nil
+}
+
+// recordAudit stores a change of an entity made by the user of ctx. A nil
+// snapshot is stored as null, e.g. before a creation or after a deletion.
+func recordAudit(ctx context.Context, auditRepo repo.AuditRepo, action, entityType string, entityId int, before, after any) error {
+	entry := &entity.AuditEntry{
+		Action:     action,
+		EntityType: entityType,
+		EntityId:   entityId,
+	}
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		entry.UserId = principal.UserId
+		entry.Username = principal.Username
+	}
+
+	var err error
+	entry.Before, err = snapshot(before)
+	if err != nil {
+		return fmt.Errorf("recordAudit: %v", err)
+	}
+	entry.After, err = snapshot(after)
+	if err != nil {
+		return fmt.Errorf("recordAudit: %v", err)
+	}
+
+	return auditRepo.CreateAuditEntry(ctx, entry)
+}
+
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(v)
+}
@@ -2,69 +2,329 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
+	"strconv"
+	"sync"
 	"time"
 	"vk-film-library/internal/entity"
 	"vk-film-library/internal/repo"
 	"vk-film-library/internal/repo/repoerrs"
+	"vk-film-library/pkg/password"
 )
 
-const (
-	salt = "15dd01c7259448d497ec85b125f11bde"
-)
+// legacySalt is the salt shared by the SHA-1 password hashes made before
+// argon2id. Such hashes are replaced when their users sign in.
+const legacySalt = "15dd01c7259448d497ec85b125f11bde"
+
+// dummyHash is verified when a user is not found, so that the response time
+// does not tell whether a username exists. It is made on the first failed sign
+// in rather than at start, hashing is too slow for every importer to pay for it.
+var dummyHash struct {
+	once sync.Once
+	hash string
+	err  error
+}
+
+// getDummyHash returns dummyHash, making it on the first call.
+func getDummyHash() (string, error) {
+	dummyHash.once.Do(func() {
+		dummyHash.hash, dummyHash.err = password.Hash("")
+	})
+	if dummyHash.err != nil {
+		return "", fmt.Errorf("getDummyHash: %v", dummyHash.err)
+	}
+
+	return dummyHash.hash, nil
+}
 
+// TokenClaims carry the user id as the subject and a random token id, so
+// that a request can be attributed to both the user and the token it used.
 type TokenClaims struct {
 	jwt.StandardClaims
+	Username string
 	UserRole string
+	// SessionId is checked on every request, so that a revoked session
+	// invalidates its access tokens before they expire.
+	SessionId int
 }
 
 type AuthService struct {
-	userRepo repo.UserRepo
-	signKey  string
-	tokenTTL time.Duration
+	transactor      repo.Transactor
+	userRepo        repo.UserRepo
+	sessionRepo     repo.SessionRepo
+	permissionRepo  repo.PermissionRepo
+	auditRepo       repo.AuditRepo
+	signKey         string
+	tokenTTL        time.Duration
+	refreshTokenTTL time.Duration
 }
 
-func NewAuthService(userRepo repo.UserRepo, signKey string, tokenTTL time.Duration) *AuthService {
+func NewAuthService(transactor repo.Transactor, userRepo repo.UserRepo, sessionRepo repo.SessionRepo,
+	permissionRepo repo.PermissionRepo, auditRepo repo.AuditRepo, signKey string, tokenTTL, refreshTokenTTL time.Duration) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
-		signKey:  signKey,
-		tokenTTL: tokenTTL,
+		transactor:      transactor,
+		userRepo:        userRepo,
+		sessionRepo:     sessionRepo,
+		permissionRepo:  permissionRepo,
+		auditRepo:       auditRepo,
+		signKey:         signKey,
+		tokenTTL:        tokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
 	}
 }
 
+// CreateUser validates the input and creates a user of its role. Every way of
+// creating a user goes through it, so that they all have the same rules.
 func (s *AuthService) CreateUser(ctx context.Context, input *entity.CreateInput) (int, error) {
+	err := input.Validate()
+	if err != nil {
+		return 0, err
+	}
+
+	passwordHash, err := password.Hash(input.Password)
+	if err != nil {
+		return 0, err
+	}
+
 	user := &entity.User{
 		Username: input.Username,
-		Password: hash(input.Password),
+		Password: passwordHash,
 		Role:     input.Role,
 	}
 
-	return s.userRepo.CreateUser(ctx, user)
+	id, err := s.userRepo.CreateUser(ctx, user)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrAlreadyExists) {
+			return 0, ErrUserAlreadyExists
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// CreateAdmin creates an admin on behalf of the admin of ctx.
+func (s *AuthService) CreateAdmin(ctx context.Context, input *entity.CreateInput) (int, error) {
+	var id int
+	err := s.transactor.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = s.CreateUser(ctx, &entity.CreateInput{
+			Username: input.Username,
+			Password: input.Password,
+			Role:     entity.UserRoleAdmin,
+		})
+		if err != nil {
+			return err
+		}
+
+		after := &entity.User{Id: id, Username: input.Username, Role: entity.UserRoleAdmin}
+		return recordAudit(ctx, s.auditRepo, entity.AuditCreate, entity.EntityUser, id, nil, after)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// PromoteUser makes an existing user an admin on behalf of the admin of ctx.
+// The new role is in the access tokens issued after the next refresh.
+func (s *AuthService) PromoteUser(ctx context.Context, username string) error {
+	return s.transactor.WithTx(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.GetUserByUsername(ctx, username)
+		if err != nil {
+			if errors.Is(err, repoerrs.ErrNotFound) {
+				return ErrUnknownUser
+			}
+			return err
+		}
+
+		// the role is changed only if it is not admin yet, so of concurrent
+		// promotions of the same user one succeeds and is audited
+		err = s.userRepo.UpdateUserRole(ctx, user.Id, entity.UserRoleAdmin)
+		if err != nil {
+			if errors.Is(err, repoerrs.ErrNotFound) {
+				return ErrUserAlreadyAdmin
+			}
+			return err
+		}
+
+		after := *user
+		after.Role = entity.UserRoleAdmin
+		return recordAudit(ctx, s.auditRepo, entity.AuditPromote, entity.EntityUser, user.Id, user, &after)
+	})
+}
+
+// BootstrapAdmin creates the first admin. It does nothing if there is an admin
+// already and reports whether the admin was created. An existing user is never
+// promoted, so that the admin username cannot be taken over by signing up first.
+// Instances starting at the same time check and create the admin one at a time,
+// so only the first of them creates it.
+func (s *AuthService) BootstrapAdmin(ctx context.Context, input *entity.CreateInput) (bool, error) {
+	var created bool
+	err := s.transactor.WithTx(ctx, func(ctx context.Context) error {
+		err := s.userRepo.LockAdminBootstrap(ctx)
+		if err != nil {
+			return err
+		}
+
+		exists, err := s.userRepo.HasUserWithRole(ctx, entity.UserRoleAdmin)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+
+		_, err = s.CreateAdmin(ctx, input)
+		if err != nil {
+			return err
+		}
+		created = true
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
 }
 
-func (s *AuthService) GenerateToken(ctx context.Context, input *entity.AuthInput) (string, error) {
+// GenerateToken signs the user in and starts a new session.
+func (s *AuthService) GenerateToken(ctx context.Context, input *entity.AuthInput) (*entity.Tokens, error) {
 	// get user from DB
-	user, err := s.userRepo.GetUserByUsernameAndPassword(ctx, input.Username, hash(input.Password))
+	user, err := s.userRepo.GetUserByUsername(ctx, input.Username)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			hash, err := getDummyHash()
+			if err != nil {
+				return nil, err
+			}
+			password.Verify(input.Password, hash)
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	// check password
+	ok, err := s.verifyPassword(ctx, user, input.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	// start session
+	refreshToken, tokenHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	session := &entity.Session{
+		UserId:    user.Id,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	}
+	sessionId, err := s.sessionRepo.CreateSession(ctx, session, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.signToken(user.Id, user.Username, user.Role, sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// RefreshToken exchanges a refresh token for new tokens of the same session.
+// A refresh token can be used once: a used one means that it was stolen from
+// the client or by the client, so the whole session is revoked.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*entity.Tokens, error) {
+	tokenHash := hashRefreshToken(refreshToken)
+	token, err := s.sessionRepo.GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if token.UsedAt != nil {
+		return nil, s.revokeReused(ctx, token.Session.Id)
+	}
+	if !token.Session.IsActive(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	nextRefreshToken, nextTokenHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	err = s.sessionRepo.RotateRefreshToken(ctx, token.Session.Id, tokenHash, nextTokenHash)
 	if err != nil {
 		if errors.Is(err, repoerrs.ErrNotFound) {
-			return "", ErrUserNotFound
+			// the token was used by a concurrent request
+			return nil, s.revokeReused(ctx, token.Session.Id)
 		}
+		return nil, err
+	}
+
+	accessToken, err := s.signToken(token.Session.UserId, token.Username, token.Role, token.Session.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: nextRefreshToken,
+	}, nil
+}
+
+func (s *AuthService) revokeReused(ctx context.Context, sessionId int) error {
+	err := s.sessionRepo.RevokeSession(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+
+	return ErrRefreshTokenReused
+}
+
+// Logout revokes the session, its access and refresh tokens stop working.
+func (s *AuthService) Logout(ctx context.Context, sessionId int) error {
+	return s.sessionRepo.RevokeSession(ctx, sessionId)
+}
+
+func (s *AuthService) signToken(userId int, username, role string, sessionId int) (string, error) {
+	tokenId, err := newTokenId()
+	if err != nil {
 		return "", err
 	}
 
-	// generate token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &TokenClaims{
 		StandardClaims: jwt.StandardClaims{
+			Id:        tokenId,
+			Subject:   strconv.Itoa(userId),
 			ExpiresAt: time.Now().Add(s.tokenTTL).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
-		UserRole: user.Role,
+		Username:  username,
+		UserRole:  role,
+		SessionId: sessionId,
 	})
 
-	// sign token
 	tokenString, err := token.SignedString([]byte(s.signKey))
 	if err != nil {
 		return "", ErrCannotSignToken
@@ -73,7 +333,9 @@ func (s *AuthService) GenerateToken(ctx context.Context, input *entity.AuthInput
 	return tokenString, nil
 }
 
-func (s *AuthService) ParseToken(accessToken string) (string, error) {
+// ParseToken checks the signature and the expiry of the access token and
+// that its session is not revoked.
+func (s *AuthService) ParseToken(ctx context.Context, accessToken string) (*entity.Principal, error) {
 	token, err := jwt.ParseWithClaims(accessToken, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -83,20 +345,107 @@ func (s *AuthService) ParseToken(accessToken string) (string, error) {
 	})
 
 	if err != nil {
-		return "", ErrCannotParseToken
+		return nil, ErrCannotParseToken
 	}
 
 	claims, ok := token.Claims.(*TokenClaims)
 	if !ok {
-		return "", ErrCannotParseToken
+		return nil, ErrCannotParseToken
+	}
+	userId, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid subject", ErrCannotParseToken)
+	}
+
+	session, err := s.sessionRepo.GetSession(ctx, claims.SessionId)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			return nil, ErrCannotParseToken
+		}
+		return nil, err
+	}
+	if !session.IsActive(time.Now()) {
+		return nil, fmt.Errorf("%w: session is revoked or expired", ErrCannotParseToken)
+	}
+
+	return &entity.Principal{
+		UserId:    userId,
+		Username:  claims.Username,
+		Role:      claims.UserRole,
+		TokenId:   claims.Id,
+		SessionId: claims.SessionId,
+	}, nil
+}
+
+// HasPermission reports whether the role has the permission. Permissions are
+// read from the database every time, so that a role change takes effect at once.
+func (s *AuthService) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	return s.permissionRepo.HasPermission(ctx, role, permission)
+}
+
+// verifyPassword checks the password of the user. A legacy SHA-1 hash or an
+// argon2id hash with outdated parameters is replaced once the password matches.
+func (s *AuthService) verifyPassword(ctx context.Context, user *entity.User, pass string) (bool, error) {
+	var ok bool
+	if password.IsHash(user.Password) {
+		var err error
+		ok, err = password.Verify(pass, user.Password)
+		if err != nil {
+			return false, err
+		}
+		if !ok || !password.NeedsRehash(user.Password) {
+			return ok, nil
+		}
+	} else {
+		ok = subtle.ConstantTimeCompare([]byte(legacyHash(pass)), []byte(user.Password)) == 1
+		if !ok {
+			return false, nil
+		}
 	}
 
-	return claims.UserRole, nil
+	passwordHash, err := password.Hash(pass)
+	if err != nil {
+		return false, err
+	}
+	err = s.userRepo.UpdateUserPassword(ctx, user.Id, passwordHash)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// newTokenId returns a random id of an access token.
+func newTokenId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("newTokenId: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// newRefreshToken makes a random refresh token and its hash to store.
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("newRefreshToken: %v", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, hashRefreshToken(token), nil
+}
+
+// hashRefreshToken uses a fast hash, since refresh tokens are random and long
+// enough not to be brute-forced.
+func hashRefreshToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
 }
 
-func hash(password string) string {
+func legacyHash(password string) string {
 	h := sha1.New()
 	h.Write([]byte(password))
 
-	return fmt.Sprintf("%x", h.Sum([]byte(salt)))
+	return fmt.Sprintf("%x", h.Sum([]byte(legacySalt)))
 }
@@ -0,0 +1,600 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo"
+	"vk-film-library/internal/repo/repoerrs"
+	"vk-film-library/pkg/password"
+)
+
+// fakeUserRepo keeps users by id.
+type fakeUserRepo struct {
+	repo.UserRepo
+
+	bootstrapMu sync.Mutex
+	mu          sync.Mutex
+	users       map[int]*entity.User
+}
+
+func (r *fakeUserRepo) CreateUser(ctx context.Context, user *entity.User) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Username == user.Username {
+			return 0, repoerrs.ErrAlreadyExists
+		}
+	}
+	created := *user
+	created.Id = len(r.users) + 1
+	r.users[created.Id] = &created
+
+	return created.Id, nil
+}
+
+func (r *fakeUserRepo) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Username == username {
+			user := *u
+			return &user, nil
+		}
+	}
+
+	return nil, repoerrs.ErrNotFound
+}
+
+func (r *fakeUserRepo) UpdateUserPassword(ctx context.Context, id int, password string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return repoerrs.ErrNotFound
+	}
+	user.Password = password
+
+	return nil
+}
+
+func (r *fakeUserRepo) HasUserWithRole(ctx context.Context, role string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Role == role {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeUserRepo) LockAdminBootstrap(ctx context.Context) error {
+	r.bootstrapMu.Lock()
+	tx := ctx.Value(fakeTxKey{}).(*fakeTx)
+	tx.unlocks = append(tx.unlocks, r.bootstrapMu.Unlock)
+
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateUserRole(ctx context.Context, id int, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.Role == role {
+		return repoerrs.ErrNotFound
+	}
+	user.Role = role
+
+	return nil
+}
+
+func TestAuthService_verifyPassword(t *testing.T) {
+	current, err := password.Hash("secret")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		stored       string
+		password     string
+		want         bool
+		wantUpgraded bool
+	}{
+		{
+			name:         "legacy hash is upgraded",
+			stored:       legacyHash("secret"),
+			password:     "secret",
+			want:         true,
+			wantUpgraded: true,
+		},
+		{
+			name:         "wrong password of legacy hash",
+			stored:       legacyHash("secret"),
+			password:     "Secret",
+			want:         false,
+			wantUpgraded: false,
+		},
+		{
+			name:         "current hash",
+			stored:       current,
+			password:     "secret",
+			want:         true,
+			wantUpgraded: false,
+		},
+		{
+			name:         "wrong password of current hash",
+			stored:       current,
+			password:     "Secret",
+			want:         false,
+			wantUpgraded: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := &entity.User{Id: 1, Username: "test_user", Password: tc.stored, Role: entity.UserRoleUser}
+			stored := *user
+			userRepo := &fakeUserRepo{users: map[int]*entity.User{1: &stored}}
+			authService := NewAuthService(nil, userRepo, nil, nil, nil, "key", 0, 0)
+
+			got, err := authService.verifyPassword(context.Background(), user, tc.password)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			if !tc.wantUpgraded {
+				assert.Equal(t, tc.stored, stored.Password)
+				return
+			}
+			assert.True(t, password.IsHash(stored.Password))
+			assert.False(t, password.NeedsRehash(stored.Password))
+			ok, err := password.Verify(tc.password, stored.Password)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestGetDummyHash(t *testing.T) {
+	hash, err := getDummyHash()
+	assert.NoError(t, err)
+	assert.True(t, password.IsHash(hash))
+	assert.False(t, password.NeedsRehash(hash))
+
+	again, err := getDummyHash()
+	assert.NoError(t, err)
+	assert.Equal(t, hash, again)
+}
+
+// fakeSessionRepo keeps sessions by id and refresh tokens by their hash.
+// onRotate runs before a rotation, as a concurrent request would.
+type fakeSessionRepo struct {
+	repo.SessionRepo
+
+	mu       sync.Mutex
+	sessions map[int]*entity.Session
+	tokens   map[string]*entity.RefreshToken
+	onRotate func()
+}
+
+func (r *fakeSessionRepo) GetSession(ctx context.Context, id int) (*entity.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, repoerrs.ErrNotFound
+	}
+	result := *session
+
+	return &result, nil
+}
+
+func (r *fakeSessionRepo) GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, repoerrs.ErrNotFound
+	}
+	result := *token
+	result.Session = *r.sessions[token.Session.Id]
+
+	return &result, nil
+}
+
+func (r *fakeSessionRepo) RotateRefreshToken(ctx context.Context, sessionId int, tokenHash, nextTokenHash string) error {
+	if r.onRotate != nil {
+		r.onRotate()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok || token.UsedAt != nil || token.Session.Id != sessionId {
+		return repoerrs.ErrNotFound
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	r.tokens[nextTokenHash] = &entity.RefreshToken{Session: entity.Session{Id: sessionId}, Username: token.Username, Role: token.Role}
+
+	return nil
+}
+
+func (r *fakeSessionRepo) RevokeSession(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return repoerrs.ErrNotFound
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+
+	return nil
+}
+
+// useRefreshToken marks the refresh token as used.
+func (r *fakeSessionRepo) useRefreshToken(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.tokens[hashRefreshToken(token)].UsedAt = &now
+}
+
+func newFakeSessionRepo(refreshToken string, session entity.Session) *fakeSessionRepo {
+	return &fakeSessionRepo{
+		sessions: map[int]*entity.Session{session.Id: &session},
+		tokens: map[string]*entity.RefreshToken{
+			hashRefreshToken(refreshToken): {Session: entity.Session{Id: session.Id}, Username: "test_user", Role: entity.UserRoleUser},
+		},
+	}
+}
+
+func TestAuthService_RefreshToken(t *testing.T) {
+	session := entity.Session{Id: 1, UserId: 2, ExpiresAt: time.Now().Add(time.Hour)}
+	sessionRepo := newFakeSessionRepo("refresh", session)
+	authService := NewAuthService(nil, nil, sessionRepo, nil, nil, "key", time.Minute, time.Hour)
+
+	tokens, err := authService.RefreshToken(context.Background(), "refresh")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "refresh", tokens.RefreshToken)
+	assert.Nil(t, sessionRepo.sessions[1].RevokedAt)
+
+	principal, err := authService.ParseToken(context.Background(), tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, principal.UserId)
+	assert.Equal(t, 1, principal.SessionId)
+
+	// the next refresh token of the session works once as well
+	_, err = authService.RefreshToken(context.Background(), tokens.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestAuthService_RefreshToken_Reused(t *testing.T) {
+	session := entity.Session{Id: 1, UserId: 2, ExpiresAt: time.Now().Add(time.Hour)}
+	sessionRepo := newFakeSessionRepo("refresh", session)
+	authService := NewAuthService(nil, nil, sessionRepo, nil, nil, "key", time.Minute, time.Hour)
+
+	tokens, err := authService.RefreshToken(context.Background(), "refresh")
+	assert.NoError(t, err)
+
+	_, err = authService.RefreshToken(context.Background(), "refresh")
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	assert.NotNil(t, sessionRepo.sessions[1].RevokedAt)
+
+	// the token issued before the reuse belongs to the revoked session
+	_, err = authService.RefreshToken(context.Background(), tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = authService.ParseToken(context.Background(), tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrCannotParseToken)
+}
+
+func TestAuthService_RefreshToken_ConcurrentUse(t *testing.T) {
+	session := entity.Session{Id: 1, UserId: 2, ExpiresAt: time.Now().Add(time.Hour)}
+	sessionRepo := newFakeSessionRepo("refresh", session)
+	// a concurrent request uses the token after it is read and before it is rotated
+	sessionRepo.onRotate = func() {
+		sessionRepo.useRefreshToken("refresh")
+	}
+	authService := NewAuthService(nil, nil, sessionRepo, nil, nil, "key", time.Minute, time.Hour)
+
+	_, err := authService.RefreshToken(context.Background(), "refresh")
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	assert.NotNil(t, sessionRepo.sessions[1].RevokedAt)
+}
+
+func TestAuthService_RefreshToken_Invalid(t *testing.T) {
+	revokedAt := time.Now().Add(-time.Minute)
+
+	testCases := []struct {
+		name    string
+		token   string
+		session entity.Session
+	}{
+		{
+			name:    "unknown token",
+			token:   "other",
+			session: entity.Session{Id: 1, UserId: 2, ExpiresAt: time.Now().Add(time.Hour)},
+		},
+		{
+			name:    "revoked session",
+			token:   "refresh",
+			session: entity.Session{Id: 1, UserId: 2, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt},
+		},
+		{
+			name:    "expired session",
+			token:   "refresh",
+			session: entity.Session{Id: 1, UserId: 2, ExpiresAt: time.Now().Add(-time.Hour)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sessionRepo := newFakeSessionRepo("refresh", tc.session)
+			authService := NewAuthService(nil, nil, sessionRepo, nil, nil, "key", time.Minute, time.Hour)
+
+			_, err := authService.RefreshToken(context.Background(), tc.token)
+			assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		})
+	}
+}
+
+func TestAuthService_ParseToken(t *testing.T) {
+	revokedAt := time.Now().Add(-time.Minute)
+
+	testCases := []struct {
+		name    string
+		session entity.Session
+		wantErr bool
+	}{
+		{
+			name:    "OK",
+			session: entity.Session{Id: 1, UserId: 2, ExpiresAt: time.Now().Add(time.Hour)},
+			wantErr: false,
+		},
+		{
+			name:    "revoked session",
+			session: entity.Session{Id: 1, UserId: 2, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt},
+			wantErr: true,
+		},
+		{
+			name:    "expired session",
+			session: entity.Session{Id: 1, UserId: 2, ExpiresAt: time.Now().Add(-time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:    "unknown session",
+			session: entity.Session{Id: 3, UserId: 2, ExpiresAt: time.Now().Add(time.Hour)},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sessionRepo := newFakeSessionRepo("refresh", tc.session)
+			authService := NewAuthService(nil, nil, sessionRepo, nil, nil, "key", time.Minute, time.Hour)
+
+			accessToken, err := authService.signToken(2, "test_user", entity.UserRoleUser, 1)
+			assert.NoError(t, err)
+
+			got, err := authService.ParseToken(context.Background(), accessToken)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrCannotParseToken)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 2, got.UserId)
+			assert.Equal(t, "test_user", got.Username)
+			assert.Equal(t, entity.UserRoleUser, got.Role)
+			assert.Equal(t, 1, got.SessionId)
+			assert.NotEmpty(t, got.TokenId)
+		})
+	}
+}
+
+func TestAuthService_ParseToken_WrongKey(t *testing.T) {
+	session := entity.Session{Id: 1, UserId: 2, ExpiresAt: time.Now().Add(time.Hour)}
+	sessionRepo := newFakeSessionRepo("refresh", session)
+	other := NewAuthService(nil, nil, sessionRepo, nil, nil, "other key", time.Minute, time.Hour)
+	authService := NewAuthService(nil, nil, sessionRepo, nil, nil, "key", time.Minute, time.Hour)
+
+	accessToken, err := other.signToken(2, "test_user", entity.UserRoleUser, 1)
+	assert.NoError(t, err)
+
+	_, err = authService.ParseToken(context.Background(), accessToken)
+	assert.ErrorIs(t, err, ErrCannotParseToken)
+}
+
+func TestAuthService_CreateAdmin(t *testing.T) {
+	transactor := &fakeTransactor{}
+	userRepo := &fakeUserRepo{users: map[int]*entity.User{}}
+	auditRepo := &fakeAuditRepo{}
+	authService := NewAuthService(transactor, userRepo, nil, nil, auditRepo, "key", time.Minute, time.Hour)
+
+	id, err := authService.CreateAdmin(context.Background(), &entity.CreateInput{Username: "admin", Password: "long-secret"})
+	assert.NoError(t, err)
+	assert.Equal(t, entity.UserRoleAdmin, userRepo.users[id].Role)
+	assert.Len(t, auditRepo.entries, 1)
+	assert.Equal(t, 1, transactor.commits)
+
+	_, err = authService.CreateAdmin(context.Background(), &entity.CreateInput{Username: "admin", Password: "long-secret"})
+	assert.ErrorIs(t, err, ErrUserAlreadyExists)
+	assert.Len(t, auditRepo.entries, 1)
+	assert.Equal(t, 1, transactor.rollbacks)
+}
+
+func TestAuthService_CreateAdmin_Invalid(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      *entity.CreateInput
+		wantFields []string
+	}{
+		{
+			name:       "empty password",
+			input:      &entity.CreateInput{Username: "admin", Password: ""},
+			wantFields: []string{"password"},
+		},
+		{
+			name:       "short password",
+			input:      &entity.CreateInput{Username: "admin", Password: "secret"},
+			wantFields: []string{"password"},
+		},
+		{
+			name:       "empty username and password",
+			input:      &entity.CreateInput{},
+			wantFields: []string{"username", "password"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := &fakeUserRepo{users: map[int]*entity.User{}}
+			auditRepo := &fakeAuditRepo{}
+			authService := NewAuthService(&fakeTransactor{}, userRepo, nil, nil, auditRepo, "key", time.Minute, time.Hour)
+
+			_, err := authService.CreateAdmin(context.Background(), tc.input)
+			var validationErr *entity.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			fields := make([]string, 0, len(validationErr.Fields))
+			for _, f := range validationErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tc.wantFields, fields)
+			assert.Empty(t, userRepo.users)
+			assert.Empty(t, auditRepo.entries)
+		})
+	}
+}
+
+func TestAuthService_BootstrapAdmin_Concurrent(t *testing.T) {
+	const instances = 10
+
+	userRepo := &fakeUserRepo{users: map[int]*entity.User{}}
+	auditRepo := &fakeAuditRepo{}
+	authService := NewAuthService(&fakeTransactor{}, userRepo, nil, nil, auditRepo, "key", time.Minute, time.Hour)
+
+	var wg sync.WaitGroup
+	errs := make([]error, instances)
+	created := make([]bool, instances)
+	for i := 0; i < instances; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			created[i], errs[i] = authService.BootstrapAdmin(context.Background(),
+				&entity.CreateInput{Username: "root", Password: "long-secret"})
+		}(i)
+	}
+	wg.Wait()
+
+	creations := 0
+	for i := range errs {
+		assert.NoError(t, errs[i])
+		if created[i] {
+			creations++
+		}
+	}
+	assert.Equal(t, 1, creations)
+	assert.Len(t, userRepo.users, 1)
+	assert.Len(t, auditRepo.entries, 1)
+}
+
+func TestAuthService_PromoteUser(t *testing.T) {
+	testCases := []struct {
+		name      string
+		username  string
+		wantErr   error
+		wantAudit bool
+	}{
+		{
+			name:      "OK",
+			username:  "test_user",
+			wantErr:   nil,
+			wantAudit: true,
+		},
+		{
+			name:      "already admin",
+			username:  "admin",
+			wantErr:   ErrUserAlreadyAdmin,
+			wantAudit: false,
+		},
+		{
+			name:      "unknown user",
+			username:  "other",
+			wantErr:   ErrUnknownUser,
+			wantAudit: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transactor := &fakeTransactor{}
+			userRepo := &fakeUserRepo{users: map[int]*entity.User{
+				1: {Id: 1, Username: "admin", Role: entity.UserRoleAdmin},
+				2: {Id: 2, Username: "test_user", Role: entity.UserRoleUser},
+			}}
+			auditRepo := &fakeAuditRepo{}
+			authService := NewAuthService(transactor, userRepo, nil, nil, auditRepo, "key", time.Minute, time.Hour)
+
+			err := authService.PromoteUser(context.Background(), tc.username)
+			assert.Equal(t, tc.wantErr, err)
+			if !tc.wantAudit {
+				assert.Empty(t, auditRepo.entries)
+				assert.Equal(t, 1, transactor.rollbacks)
+				return
+			}
+			assert.Equal(t, entity.UserRoleAdmin, userRepo.users[2].Role)
+			assert.Len(t, auditRepo.entries, 1)
+			assert.Equal(t, entity.AuditPromote, auditRepo.entries[0].Action)
+			assert.JSONEq(t, `{"id":2,"username":"test_user","role":"user"}`, string(auditRepo.entries[0].Before))
+			assert.JSONEq(t, `{"id":2,"username":"test_user","role":"admin"}`, string(auditRepo.entries[0].After))
+			assert.Equal(t, 1, transactor.commits)
+		})
+	}
+}
+
+func TestAuthService_PromoteUser_Concurrent(t *testing.T) {
+	const promotions = 10
+
+	transactor := &fakeTransactor{}
+	userRepo := &fakeUserRepo{users: map[int]*entity.User{
+		1: {Id: 1, Username: "test_user", Role: entity.UserRoleUser},
+	}}
+	auditRepo := &fakeAuditRepo{}
+	authService := NewAuthService(transactor, userRepo, nil, nil, auditRepo, "key", time.Minute, time.Hour)
+
+	var wg sync.WaitGroup
+	errs := make([]error, promotions)
+	for i := 0; i < promotions; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = authService.PromoteUser(context.Background(), "test_user")
+		}(i)
+	}
+	wg.Wait()
+
+	promoted := 0
+	for _, err := range errs {
+		if err == nil {
+			promoted++
+			continue
+		}
+		assert.Equal(t, ErrUserAlreadyAdmin, err)
+	}
+	assert.Equal(t, 1, promoted)
+	assert.Len(t, auditRepo.entries, 1)
+	assert.Equal(t, 1, transactor.commits)
+}
@@ -1,14 +1,79 @@
 package service
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 var (
 	ErrUserNotFound      = fmt.Errorf("user not found")
 	ErrUserAlreadyExists = fmt.Errorf("user already exists")
+	ErrUnknownUser       = fmt.Errorf("unknown user")
+	ErrUserAlreadyAdmin  = fmt.Errorf("user is already an admin")
 
 	ErrCannotSignToken  = fmt.Errorf("cannot sign token")
 	ErrCannotParseToken = fmt.Errorf("cannot parse token")
 
-	ErrActorNotFound = fmt.Errorf("actor not found")
-	ErrFilmNotFound  = fmt.Errorf("film not found")
+	ErrInvalidRefreshToken = fmt.Errorf("invalid refresh token")
+	ErrRefreshTokenReused  = fmt.Errorf("refresh token is reused, session is revoked")
+
+	ErrActorNotFound   = fmt.Errorf("actor not found")
+	ErrFilmNotFound    = fmt.Errorf("film not found")
+	ErrActorNotInTrash = fmt.Errorf("actor not found in trash")
+	ErrFilmNotInTrash  = fmt.Errorf("film not found in trash")
+
+	ErrRevisionNotFound = fmt.Errorf("revision not found")
+
+	ErrGenreNotFound      = fmt.Errorf("genre not found")
+	ErrGenreAlreadyExists = fmt.Errorf("genre already exists")
+
+	ErrInvalidFilmFilter  = fmt.Errorf("invalid film filter")
+	ErrInvalidFilmSearch  = fmt.Errorf("invalid film search")
+	ErrInvalidAuditFilter = fmt.Errorf("invalid audit filter")
+	ErrEmptyName          = fmt.Errorf("name is empty")
+	ErrInvalidCursor      = fmt.Errorf("invalid cursor")
+	ErrInvalidPageLimit   = fmt.Errorf("invalid page limit")
 )
+
+// UnknownActorsError is returned when the cast or the crew of a film refers to
+// actors that do not exist. Names and Ids list all of them, so that a client
+// can fix the input at once.
+type UnknownActorsError struct {
+	Names []string
+	Ids   []int
+}
+
+func (e *UnknownActorsError) Error() string {
+	parts := append([]string(nil), e.Names...)
+	for _, id := range e.Ids {
+		parts = append(parts, "id "+strconv.Itoa(id))
+	}
+
+	return fmt.Sprintf("unknown actors: %s", strings.Join(parts, ", "))
+}
+
+// AmbiguousActorsError is returned when a name in the cast or the crew of a
+// film belongs to several actors. Such actors have to be given by id.
+type AmbiguousActorsError struct {
+	Names []string
+}
+
+func (e *AmbiguousActorsError) Error() string {
+	return fmt.Sprintf("names of several actors, give their ids: %s", strings.Join(e.Names, ", "))
+}
+
+// UnknownGenresError is returned when a film refers to genres that do not
+// exist. Ids lists all of them.
+type UnknownGenresError struct {
+	Ids []int
+}
+
+func (e *UnknownGenresError) Error() string {
+	ids := make([]string, 0, len(e.Ids))
+	for _, id := range e.Ids {
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	return fmt.Sprintf("unknown genres: %s", strings.Join(ids, ", "))
+}
@@ -2,18 +2,32 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode"
 	"vk-film-library/internal/entity"
 	"vk-film-library/internal/repo"
 	"vk-film-library/internal/repo/repoerrs"
 )
 
 type FilmService struct {
-	repo repo.FilmRepo
+	transactor   repo.Transactor
+	repo         repo.FilmRepo
+	actorRepo    repo.ActorRepo
+	auditRepo    repo.AuditRepo
+	revisionRepo repo.RevisionRepo
 }
 
-func NewFilmService(repo repo.FilmRepo) *FilmService {
+func NewFilmService(transactor repo.Transactor, repo repo.FilmRepo, actorRepo repo.ActorRepo, auditRepo repo.AuditRepo,
+	revisionRepo repo.RevisionRepo) *FilmService {
 	return &FilmService{
-		repo: repo,
+		transactor:   transactor,
+		repo:         repo,
+		actorRepo:    actorRepo,
+		auditRepo:    auditRepo,
+		revisionRepo: revisionRepo,
 	}
 }
 
@@ -23,37 +37,312 @@ func (f *FilmService) CreateFilm(ctx context.Context, input *entity.FilmCreateIn
 		return 0, err
 	}
 
+	genres := make([]*entity.Genre, 0, len(input.Genres))
+	for _, id := range input.Genres {
+		genres = append(genres, &entity.Genre{Id: id})
+	}
+
 	film := &entity.Film{
 		Name:        input.Name,
 		Description: input.Description,
-		CreatedAt:   input.CreatedAt,
+		CreatedAt:   time.Time(input.CreatedAt),
 		Rating:      input.Rating,
-		Actors:      input.Actors,
+		Actors:      entity.NewFilmCast(input.Actors),
+		Crew:        entity.NewFilmCrew(input.Crew),
+		Genres:      genres,
 	}
 
-	return f.repo.CreateFilm(ctx, film)
+	var id int
+	err = f.transactor.WithTx(ctx, func(ctx context.Context) error {
+		id, err = f.repo.CreateFilm(ctx, film)
+		if err != nil {
+			return filmReferenceError(err)
+		}
+
+		after, err := f.repo.GetFilmByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, f.auditRepo, entity.AuditCreate, entity.EntityFilm, id, nil, after)
+		if err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, f.revisionRepo, entity.EntityFilm, id, nil, entity.NewFilmSnapshot(after))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
 }
 
-func (f *FilmService) GetSortFilms(ctx context.Context, sort string) ([]*entity.Film, error) {
-	return f.repo.GetSortFilms(ctx, sort)
+func (f *FilmService) GetFilmByID(ctx context.Context, id int) (*entity.Film, error) {
+	film, err := f.repo.GetFilmByID(ctx, id)
+	if err != nil {
+		if err == repoerrs.ErrNotFound {
+			return nil, ErrFilmNotFound
+		}
+		return nil, err
+	}
+
+	return film, nil
 }
 
-func (f *FilmService) GetFilmsByName(ctx context.Context, namePart string) ([]*entity.Film, error) {
-	return f.repo.GetFilmsByName(ctx, namePart)
+func (f *FilmService) GetFilms(ctx context.Context, filter *entity.FilmFilter, page *entity.PageInput) ([]*entity.Film, string, error) {
+	err := filter.Validate()
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidFilmFilter, err)
+	}
+	err = preparePage(page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	films, nextCursor, err := f.repo.GetFilms(ctx, filter, page)
+	if err != nil {
+		if err == repoerrs.ErrInvalidCursor {
+			return nil, "", ErrInvalidCursor
+		}
+		return nil, "", err
+	}
+
+	return films, nextCursor, nil
 }
 
-func (f *FilmService) GetFilmsByActor(ctx context.Context, namePart string) ([]*entity.Film, error) {
-	return f.repo.GetFilmsByActor(ctx, namePart)
+func (f *FilmService) CountFilmGenres(ctx context.Context, filter *entity.FilmFilter) ([]*entity.GenreCount, error) {
+	err := filter.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilmFilter, err)
+	}
+
+	return f.repo.CountFilmGenres(ctx, filter)
 }
 
-func (f *FilmService) DeleteFilm(ctx context.Context, id int) error {
-	err := f.repo.DeleteFilm(ctx, id)
+func (f *FilmService) GetSortFilms(ctx context.Context, sort []entity.SortKey, page *entity.PageInput) ([]*entity.Film, string, error) {
+	return f.GetFilms(ctx, &entity.FilmFilter{Sort: sort}, page)
+}
+
+func (f *FilmService) GetFilmsByName(ctx context.Context, namePart string, page *entity.PageInput) ([]*entity.Film, string, error) {
+	return f.GetFilms(ctx, &entity.FilmFilter{Name: namePart}, page)
+}
+
+// GetFilmsByActor returns a page of the films of the actors whose names contain namePart.
+// If there are no such actors, the films are empty and similar actor names are
+// suggested instead.
+func (f *FilmService) GetFilmsByActor(ctx context.Context, namePart string, page *entity.PageInput) (*entity.ActorFilms, error) {
+	matches, err := f.actorRepo.FindActorsByName(ctx, namePart, nameSearchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	search := entity.NewNameSearchResult(matches)
+	if len(search.Matches) == 0 {
+		return &entity.ActorFilms{Films: make([]*entity.Film, 0), DidYouMean: search.DidYouMean}, nil
+	}
+
+	films, nextCursor, err := f.GetFilms(ctx, &entity.FilmFilter{ActorName: namePart}, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ActorFilms{Films: films, NextCursor: nextCursor}, nil
+}
+
+func (f *FilmService) SearchFilms(ctx context.Context, input *entity.FilmSearchInput) ([]*entity.FilmSearchResult, error) {
+	if input.Lang == "" {
+		input.Lang = detectLanguage(input.Query)
+	}
+	if input.Limit == 0 {
+		input.Limit = entity.DefaultPageLimit
+	}
+	err := input.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilmSearch, err)
+	}
+
+	return f.repo.SearchFilms(ctx, input)
+}
+
+// detectLanguage guesses the search language of the query: Russian if it has
+// any cyrillic letters, English otherwise.
+func detectLanguage(query string) string {
+	for _, r := range query {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return "ru"
+		}
+	}
+
+	return "en"
+}
+
+func (f *FilmService) FindFilms(ctx context.Context, name string) (*entity.NameSearchResult, error) {
+	if name == "" {
+		return nil, ErrEmptyName
+	}
+
+	matches, err := f.repo.FindFilmsByName(ctx, name, nameSearchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewNameSearchResult(matches), nil
+}
+
+func (f *FilmService) EditFilm(ctx context.Context, id int, input *entity.FilmEditInput) error {
+	return f.editFilm(ctx, id, input, entity.AuditEdit)
+}
+
+// editFilm changes a film and records the change with the audit action in
+// the same transaction.
+func (f *FilmService) editFilm(ctx context.Context, id int, input *entity.FilmEditInput, action string) error {
+	err := input.Validate()
+	if err != nil {
+		return err
+	}
+
+	return f.transactor.WithTx(ctx, func(ctx context.Context) error {
+		before, err := f.lockFilm(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = f.repo.EditFilm(ctx, id, input)
+		if err != nil {
+			if err == repoerrs.ErrNotFound {
+				return ErrFilmNotFound
+			}
+			return filmReferenceError(err)
+		}
+
+		after, err := f.repo.GetFilmByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(ctx, f.auditRepo, action, entity.EntityFilm, id, before, after)
+		if err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, f.revisionRepo, entity.EntityFilm, id, entity.NewFilmSnapshot(before), entity.NewFilmSnapshot(after))
+	})
+}
+
+// filmReferenceError replaces the repository errors of unknown or ambiguous
+// actors and unknown genres of a film with the service ones.
+func filmReferenceError(err error) error {
+	var actorsNotFoundErr *repoerrs.ActorsNotFoundError
+	if errors.As(err, &actorsNotFoundErr) {
+		return &UnknownActorsError{Names: actorsNotFoundErr.Names, Ids: actorsNotFoundErr.Ids}
+	}
+	var ambiguousActorsErr *repoerrs.AmbiguousActorsError
+	if errors.As(err, &ambiguousActorsErr) {
+		return &AmbiguousActorsError{Names: ambiguousActorsErr.Names}
+	}
+	var genresNotFoundErr *repoerrs.GenresNotFoundError
+	if errors.As(err, &genresNotFoundErr) {
+		return &UnknownGenresError{Ids: genresNotFoundErr.Ids}
+	}
+
+	return err
+}
+
+// lockFilm locks a film until the end of the transaction of ctx and returns
+// it, so that the state before a change is not changed concurrently.
+func (f *FilmService) lockFilm(ctx context.Context, id int) (*entity.Film, error) {
+	err := f.repo.LockFilm(ctx, id)
 	if err != nil {
 		if err == repoerrs.ErrNotFound {
-			return ErrFilmNotFound
+			return nil, ErrFilmNotFound
+		}
+		return nil, err
+	}
+
+	return f.repo.GetFilmByID(ctx, id)
+}
+
+func (f *FilmService) DeleteFilm(ctx context.Context, id int) error {
+	return f.transactor.WithTx(ctx, func(ctx context.Context) error {
+		before, err := f.lockFilm(ctx, id)
+		if err != nil {
+			return err
 		}
+
+		err = f.repo.DeleteFilm(ctx, id)
+		if err != nil {
+			if err == repoerrs.ErrNotFound {
+				return ErrFilmNotFound
+			}
+			return err
+		}
+
+		return recordAudit(ctx, f.auditRepo, entity.AuditDelete, entity.EntityFilm, id, before, nil)
+	})
+}
+
+func (f *FilmService) GetDeletedFilms(ctx context.Context) ([]*entity.TrashItem, error) {
+	return f.repo.GetDeletedFilms(ctx)
+}
+
+func (f *FilmService) RestoreFilm(ctx context.Context, id int) error {
+	return f.transactor.WithTx(ctx, func(ctx context.Context) error {
+		err := f.repo.RestoreFilm(ctx, id)
+		if err != nil {
+			if err == repoerrs.ErrNotFound {
+				return ErrFilmNotInTrash
+			}
+			return err
+		}
+
+		after, err := f.repo.GetFilmByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, f.auditRepo, entity.AuditRestore, entity.EntityFilm, id, nil, after)
+	})
+}
+
+// PurgeFilm permanently deletes a film from the trash. The last snapshot of the
+// film is the one recorded when it was deleted.
+func (f *FilmService) PurgeFilm(ctx context.Context, id int) error {
+	return f.transactor.WithTx(ctx, func(ctx context.Context) error {
+		err := f.repo.PurgeFilm(ctx, id)
+		if err != nil {
+			if err == repoerrs.ErrNotFound {
+				return ErrFilmNotInTrash
+			}
+			return err
+		}
+
+		return recordAudit(ctx, f.auditRepo, entity.AuditPurge, entity.EntityFilm, id, nil, nil)
+	})
+}
+
+func (f *FilmService) GetFilmRevisions(ctx context.Context, id int) ([]*entity.Revision, error) {
+	return f.revisionRepo.GetRevisions(ctx, entity.EntityFilm, id)
+}
+
+func (f *FilmService) DiffFilmRevisions(ctx context.Context, id, from, to int) (*entity.RevisionDiff, error) {
+	return diffRevisions(ctx, f.revisionRepo, entity.EntityFilm, id, from, to)
+}
+
+// RevertFilm brings a film back to the state of one of its revisions. The
+// state is recorded as a new revision, so a revert can be reverted as well.
+// The cast and the crew are restored by person id, a person trashed since the
+// revision makes the revert fail with UnknownActorsError.
+func (f *FilmService) RevertFilm(ctx context.Context, id, number int) error {
+	revision, err := getRevision(ctx, f.revisionRepo, entity.EntityFilm, id, number)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	var snapshot entity.FilmSnapshot
+	err = json.Unmarshal(revision.Data, &snapshot)
+	if err != nil {
+		return fmt.Errorf("FilmService RevertFilm: %v", err)
+	}
+
+	return f.editFilm(ctx, id, snapshot.EditInput(), entity.AuditRevert)
 }
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo"
+	"vk-film-library/internal/repo/repoerrs"
+)
+
+type GenreService struct {
+	repo repo.GenreRepo
+}
+
+func NewGenreService(repo repo.GenreRepo) *GenreService {
+	return &GenreService{
+		repo: repo,
+	}
+}
+
+func (g *GenreService) CreateGenre(ctx context.Context, input *entity.GenreInput) (int, error) {
+	err := input.Validate()
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := g.repo.CreateGenre(ctx, &entity.Genre{Name: input.Name})
+	if err != nil {
+		if err == repoerrs.ErrAlreadyExists {
+			return 0, ErrGenreAlreadyExists
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (g *GenreService) GetGenreByID(ctx context.Context, id int) (*entity.Genre, error) {
+	genre, err := g.repo.GetGenreByID(ctx, id)
+	if err != nil {
+		if err == repoerrs.ErrNotFound {
+			return nil, ErrGenreNotFound
+		}
+		return nil, err
+	}
+
+	return genre, nil
+}
+
+func (g *GenreService) GetAllGenres(ctx context.Context) ([]*entity.Genre, error) {
+	return g.repo.GetAllGenres(ctx)
+}
+
+func (g *GenreService) EditGenre(ctx context.Context, id int, input *entity.GenreInput) error {
+	err := input.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = g.repo.EditGenre(ctx, &entity.Genre{Id: id, Name: input.Name})
+	if err != nil {
+		if err == repoerrs.ErrNotFound {
+			return ErrGenreNotFound
+		}
+		if err == repoerrs.ErrAlreadyExists {
+			return ErrGenreAlreadyExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (g *GenreService) DeleteGenre(ctx context.Context, id int) error {
+	err := g.repo.DeleteGenre(ctx, id)
+	if err != nil {
+		if err == repoerrs.ErrNotFound {
+			return ErrGenreNotFound
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"vk-film-library/internal/entity"
+)
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the user on whose behalf the request is made.
+func WithPrincipal(ctx context.Context, principal *entity.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the user stored by WithPrincipal.
+func PrincipalFromContext(ctx context.Context) (*entity.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*entity.Principal)
+	return principal, ok
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo"
+	"vk-film-library/internal/repo/repoerrs"
+)
+
+// recordRevision stores the snapshot of an entity after a change made by the
+// user of ctx as its next revision. An entity created before revisions were
+// kept gets the snapshot before its first change as revision 1 without a user,
+// so that it can be reverted to. before is nil for a creation.
+// It must be called in the transaction of the change after the entity is
+// locked, so that concurrent changes cannot both add revision 1.
+func recordRevision(ctx context.Context, revisionRepo repo.RevisionRepo, entityType string, entityId int, before, after any) error {
+	if before != nil {
+		revisions, err := revisionRepo.GetRevisions(ctx, entityType, entityId)
+		if err != nil {
+			return err
+		}
+		if len(revisions) == 0 {
+			err = createRevision(ctx, revisionRepo, &entity.Revision{EntityType: entityType, EntityId: entityId}, before)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	revision := &entity.Revision{
+		EntityType: entityType,
+		EntityId:   entityId,
+	}
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		revision.UserId = principal.UserId
+		revision.Username = principal.Username
+	}
+
+	return createRevision(ctx, revisionRepo, revision, after)
+}
+
+func createRevision(ctx context.Context, revisionRepo repo.RevisionRepo, revision *entity.Revision, data any) error {
+	var err error
+	revision.Data, err = json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("createRevision: %v", err)
+	}
+
+	_, err = revisionRepo.CreateRevision(ctx, revision)
+	return err
+}
+
+func getRevision(ctx context.Context, revisionRepo repo.RevisionRepo, entityType string, entityId, number int) (*entity.Revision, error) {
+	revision, err := revisionRepo.GetRevision(ctx, entityType, entityId, number)
+	if err != nil {
+		if err == repoerrs.ErrNotFound {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+func diffRevisions(ctx context.Context, revisionRepo repo.RevisionRepo, entityType string, entityId, from, to int) (*entity.RevisionDiff, error) {
+	fromRevision, err := getRevision(ctx, revisionRepo, entityType, entityId, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := getRevision(ctx, revisionRepo, entityType, entityId, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.DiffRevisions(fromRevision, toRevision)
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+	"vk-film-library/internal/entity"
+	"vk-film-library/internal/repo"
+	"vk-film-library/internal/repo/repoerrs"
+)
+
+type fakeTxKey struct{}
+
+// fakeTx collects the unlock functions of the locks taken in a transaction.
+type fakeTx struct {
+	unlocks []func()
+}
+
+// fakeTransactor runs functions without a database. Locks taken in a
+// transaction are released when it ends, like row locks in postgres.
+type fakeTransactor struct {
+	mu        sync.Mutex
+	commits   int
+	rollbacks int
+}
+
+func (t *fakeTransactor) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(fakeTxKey{}).(*fakeTx); ok {
+		return fn(ctx)
+	}
+
+	tx := &fakeTx{}
+	err := fn(context.WithValue(ctx, fakeTxKey{}, tx))
+	for _, unlock := range tx.unlocks {
+		unlock()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.rollbacks++
+		return err
+	}
+	t.commits++
+
+	return nil
+}
+
+// fakeFilmRepo keeps a single film and the names of the actors it can refer
+// to by id.
+type fakeFilmRepo struct {
+	repo.FilmRepo
+
+	rowMu  sync.Mutex
+	mu     sync.Mutex
+	film   entity.Film
+	actors map[int]string
+}
+
+func (r *fakeFilmRepo) LockFilm(ctx context.Context, id int) error {
+	if id != r.film.Id {
+		return repoerrs.ErrNotFound
+	}
+
+	r.rowMu.Lock()
+	tx := ctx.Value(fakeTxKey{}).(*fakeTx)
+	tx.unlocks = append(tx.unlocks, r.rowMu.Unlock)
+
+	return nil
+}
+
+func (r *fakeFilmRepo) GetFilmByID(ctx context.Context, id int) (*entity.Film, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id != r.film.Id {
+		return nil, repoerrs.ErrNotFound
+	}
+	film := r.film
+
+	return &film, nil
+}
+
+func (r *fakeFilmRepo) EditFilm(ctx context.Context, id int, input *entity.FilmEditInput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if input.Name != nil {
+		r.film.Name = *input.Name
+	}
+	if input.Actors != nil {
+		cast := entity.NewFilmCast(*input.Actors)
+		for _, actor := range cast {
+			if actor.Id == 0 {
+				actor.Id = r.actorIdByName(actor.Name)
+			}
+			name, ok := r.actors[actor.Id]
+			if !ok {
+				return &repoerrs.ActorsNotFoundError{Ids: []int{actor.Id}}
+			}
+			actor.Name = name
+		}
+		r.film.Actors = cast
+	}
+
+	return nil
+}
+
+// actorIdByName returns the smallest id of the actors with the name.
+func (r *fakeFilmRepo) actorIdByName(name string) int {
+	ids := make([]int, 0, len(r.actors))
+	for id, actorName := range r.actors {
+		if actorName == name {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	if len(ids) == 0 {
+		return 0
+	}
+
+	return ids[0]
+}
+
+type fakeAuditRepo struct {
+	repo.AuditRepo
+
+	mu      sync.Mutex
+	entries []*entity.AuditEntry
+}
+
+func (r *fakeAuditRepo) CreateAuditEntry(ctx context.Context, entry *entity.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, entry)
+
+	return nil
+}
+
+// fakeRevisionRepo numbers revisions like the database would without a lock:
+// the next number is read and stored in separate steps, so concurrent callers
+// that are not serialized get the same number and fail.
+type fakeRevisionRepo struct {
+	repo.RevisionRepo
+
+	mu        sync.Mutex
+	revisions []*entity.Revision
+	err       error
+}
+
+func (r *fakeRevisionRepo) GetRevisions(ctx context.Context, entityType string, entityId int) ([]*entity.Revision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*entity.Revision(nil), r.revisions...), nil
+}
+
+func (r *fakeRevisionRepo) GetRevision(ctx context.Context, entityType string, entityId, number int) (*entity.Revision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rev := range r.revisions {
+		if rev.Number == number {
+			return rev, nil
+		}
+	}
+
+	return nil, repoerrs.ErrNotFound
+}
+
+func (r *fakeRevisionRepo) CreateRevision(ctx context.Context, revision *entity.Revision) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	r.mu.Lock()
+	number := len(r.revisions) + 1
+	r.mu.Unlock()
+
+	runtime.Gosched()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rev := range r.revisions {
+		if rev.Number == number {
+			return 0, repoerrs.ErrAlreadyExists
+		}
+	}
+	revision.Number = number
+	r.revisions = append(r.revisions, revision)
+
+	return number, nil
+}
+
+func TestFilmService_EditFilm_ConcurrentRevisions(t *testing.T) {
+	const edits = 20
+
+	transactor := &fakeTransactor{}
+	filmRepo := &fakeFilmRepo{film: entity.Film{Id: 1, Name: "murder"}}
+	auditRepo := &fakeAuditRepo{}
+	revisionRepo := &fakeRevisionRepo{}
+	filmService := NewFilmService(transactor, filmRepo, nil, auditRepo, revisionRepo)
+
+	var wg sync.WaitGroup
+	errs := make([]error, edits)
+	for i := 0; i < edits; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("murder %d", i)
+			errs[i] = filmService.EditFilm(context.Background(), 1, &entity.FilmEditInput{Name: &name})
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	numbers := make([]int, 0, len(revisionRepo.revisions))
+	for _, rev := range revisionRepo.revisions {
+		numbers = append(numbers, rev.Number)
+	}
+	sort.Ints(numbers)
+	want := make([]int, 0, edits+1)
+	for i := 1; i <= edits+1; i++ {
+		want = append(want, i)
+	}
+	// revision 1 is the film before its first edit
+	assert.Equal(t, want, numbers)
+	assert.Equal(t, 0, revisionRepo.revisions[0].UserId)
+	assert.Len(t, auditRepo.entries, edits)
+	assert.Equal(t, edits, transactor.commits)
+}
+
+func TestFilmService_EditFilm_RevisionError(t *testing.T) {
+	someErr := errors.New("some error")
+
+	transactor := &fakeTransactor{}
+	filmRepo := &fakeFilmRepo{film: entity.Film{Id: 1, Name: "murder"}}
+	revisionRepo := &fakeRevisionRepo{err: someErr}
+	filmService := NewFilmService(transactor, filmRepo, nil, &fakeAuditRepo{}, revisionRepo)
+
+	name := "murder 2"
+	err := filmService.EditFilm(context.Background(), 1, &entity.FilmEditInput{Name: &name})
+	assert.Equal(t, someErr, err)
+	assert.Equal(t, 0, transactor.commits)
+	assert.Equal(t, 1, transactor.rollbacks)
+
+	// a retry after the failure is not blocked by the lock of the failed edit
+	revisionRepo.err = nil
+	err = filmService.EditFilm(context.Background(), 1, &entity.FilmEditInput{Name: &name})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, transactor.commits)
+	assert.Len(t, revisionRepo.revisions, 2)
+}
+
+func TestFilmService_RevertFilm_RenamedActor(t *testing.T) {
+	transactor := &fakeTransactor{}
+	filmRepo := &fakeFilmRepo{
+		film: entity.Film{
+			Id:        1,
+			Name:      "murder",
+			CreatedAt: time.Date(2010, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Actors:    []*entity.FilmActor{{Id: 1, Name: "lenna", Billing: 1, CreditType: entity.CreditLead}},
+		},
+		actors: map[int]string{1: "lenna", 2: "bob"},
+	}
+	revisionRepo := &fakeRevisionRepo{}
+	filmService := NewFilmService(transactor, filmRepo, nil, &fakeAuditRepo{}, revisionRepo)
+
+	cast := []entity.CastInput{{ActorId: 2}}
+	err := filmService.EditFilm(context.Background(), 1, &entity.FilmEditInput{Actors: &cast})
+	assert.NoError(t, err)
+
+	// the actor of revision 1 is renamed and another actor takes the old name
+	filmRepo.actors[1] = "lena"
+	filmRepo.actors[3] = "lenna"
+
+	err = filmService.RevertFilm(context.Background(), 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.FilmActor{{Id: 1, Name: "lena", Billing: 1, CreditType: entity.CreditLead}}, filmRepo.film.Actors)
+
+	// an actor gone since the revision fails the revert instead of being replaced
+	delete(filmRepo.actors, 1)
+	err = filmService.RevertFilm(context.Background(), 1, 1)
+	var unknownActorsErr *UnknownActorsError
+	assert.ErrorAs(t, err, &unknownActorsErr)
+	assert.Equal(t, []int{1}, unknownActorsErr.Ids)
+}
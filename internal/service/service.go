@@ -9,42 +9,100 @@ import (
 
 type Auth interface {
 	CreateUser(ctx context.Context, input *entity.CreateInput) (int, error)
-	GenerateToken(ctx context.Context, input *entity.AuthInput) (string, error)
-	ParseToken(token string) (string, error)
+	CreateAdmin(ctx context.Context, input *entity.CreateInput) (int, error)
+	PromoteUser(ctx context.Context, username string) error
+	BootstrapAdmin(ctx context.Context, input *entity.CreateInput) (bool, error)
+	GenerateToken(ctx context.Context, input *entity.AuthInput) (*entity.Tokens, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*entity.Tokens, error)
+	Logout(ctx context.Context, sessionId int) error
+	ParseToken(ctx context.Context, token string) (*entity.Principal, error)
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
 }
 
 type Actor interface {
 	CreateActor(ctx context.Context, input *entity.ActorCreateInput) (int, error)
-	GetAllActors(ctx context.Context) ([]*entity.Actor, error)
-	EditActor(ctx context.Context, actor *entity.Actor) error
+	GetActorByID(ctx context.Context, id int) (*entity.Actor, error)
+	GetAllActors(ctx context.Context, page *entity.PageInput) ([]*entity.Actor, string, error)
+	FindActors(ctx context.Context, name string) (*entity.NameSearchResult, error)
+	EditActor(ctx context.Context, input *entity.ActorEditInput) error
 	DeleteActor(ctx context.Context, id int) error
+	GetDeletedActors(ctx context.Context) ([]*entity.TrashItem, error)
+	RestoreActor(ctx context.Context, id int) error
+	PurgeActor(ctx context.Context, id int) error
+	GetActorRevisions(ctx context.Context, id int) ([]*entity.Revision, error)
+	DiffActorRevisions(ctx context.Context, id, from, to int) (*entity.RevisionDiff, error)
+	RevertActor(ctx context.Context, id, number int) error
 }
 
 type Film interface {
 	CreateFilm(ctx context.Context, input *entity.FilmCreateInput) (int, error)
-	GetSortFilms(ctx context.Context, sort string) ([]*entity.Film, error)
-	GetFilmsByName(ctx context.Context, namePart string) ([]*entity.Film, error)
-	GetFilmsByActor(ctx context.Context, namePart string) ([]*entity.Film, error)
+	GetFilmByID(ctx context.Context, id int) (*entity.Film, error)
+	GetFilms(ctx context.Context, filter *entity.FilmFilter, page *entity.PageInput) ([]*entity.Film, string, error)
+	CountFilmGenres(ctx context.Context, filter *entity.FilmFilter) ([]*entity.GenreCount, error)
+	GetSortFilms(ctx context.Context, sort []entity.SortKey, page *entity.PageInput) ([]*entity.Film, string, error)
+	GetFilmsByName(ctx context.Context, namePart string, page *entity.PageInput) ([]*entity.Film, string, error)
+	GetFilmsByActor(ctx context.Context, namePart string, page *entity.PageInput) (*entity.ActorFilms, error)
+	SearchFilms(ctx context.Context, input *entity.FilmSearchInput) ([]*entity.FilmSearchResult, error)
+	FindFilms(ctx context.Context, name string) (*entity.NameSearchResult, error)
+	EditFilm(ctx context.Context, id int, input *entity.FilmEditInput) error
 	DeleteFilm(ctx context.Context, id int) error
+	GetDeletedFilms(ctx context.Context) ([]*entity.TrashItem, error)
+	RestoreFilm(ctx context.Context, id int) error
+	PurgeFilm(ctx context.Context, id int) error
+	GetFilmRevisions(ctx context.Context, id int) ([]*entity.Revision, error)
+	DiffFilmRevisions(ctx context.Context, id, from, to int) (*entity.RevisionDiff, error)
+	RevertFilm(ctx context.Context, id, number int) error
+}
+
+type Genre interface {
+	CreateGenre(ctx context.Context, input *entity.GenreInput) (int, error)
+	GetGenreByID(ctx context.Context, id int) (*entity.Genre, error)
+	GetAllGenres(ctx context.Context) ([]*entity.Genre, error)
+	EditGenre(ctx context.Context, id int, input *entity.GenreInput) error
+	DeleteGenre(ctx context.Context, id int) error
+}
+
+type Audit interface {
+	GetAuditLog(ctx context.Context, filter *entity.AuditFilter, page *entity.PageInput) ([]*entity.AuditEntry, string, error)
 }
 
 type Services struct {
 	Auth  Auth
 	Actor Actor
 	Film  Film
+	Genre Genre
+	Audit Audit
 }
 
 type ServicesDependencies struct {
 	Repos *repo.Repositories
 
-	SignKey  string
-	TokenTTL time.Duration
+	SignKey         string
+	TokenTTL        time.Duration
+	RefreshTokenTTL time.Duration
 }
 
 func NewServices(deps ServicesDependencies) *Services {
 	return &Services{
-		Auth:  NewAuthService(deps.Repos.UserRepo, deps.SignKey, deps.TokenTTL),
-		Actor: NewActorService(deps.Repos.ActorRepo),
-		Film:  NewFilmService(deps.Repos.FilmRepo),
+		Auth:  NewAuthService(deps.Repos.Transactor, deps.Repos.UserRepo, deps.Repos.SessionRepo, deps.Repos.PermissionRepo, deps.Repos.AuditRepo, deps.SignKey, deps.TokenTTL, deps.RefreshTokenTTL),
+		Actor: NewActorService(deps.Repos.Transactor, deps.Repos.ActorRepo, deps.Repos.AuditRepo, deps.Repos.RevisionRepo),
+		Film:  NewFilmService(deps.Repos.Transactor, deps.Repos.FilmRepo, deps.Repos.ActorRepo, deps.Repos.AuditRepo, deps.Repos.RevisionRepo),
+		Genre: NewGenreService(deps.Repos.GenreRepo),
+		Audit: NewAuditService(deps.Repos.AuditRepo),
+	}
+}
+
+// nameSearchLimit is the number of exact and similar matches of a name search.
+const nameSearchLimit = 10
+
+// preparePage sets the default limit for a page and checks the limit bounds.
+func preparePage(page *entity.PageInput) error {
+	if page.Limit == 0 {
+		page.Limit = entity.DefaultPageLimit
 	}
+	if page.Limit < 1 || page.Limit > entity.MaxPageLimit {
+		return ErrInvalidPageLimit
+	}
+
+	return nil
 }
@@ -0,0 +1,304 @@
+// Package migrate applies numbered SQL migrations to a postgres database and
+// keeps track of the applied ones in the schema_migrations table.
+//
+// A migration is a pair of files NNN_name.up.sql and NNN_name.down.sql, where
+// NNN is the version of the migration. Every migration is applied in its own
+// transaction together with its row in schema_migrations.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+	"vk-film-library/pkg/postgres"
+)
+
+// lockId is the key of the advisory lock that serializes concurrent migrations.
+const lockId = 7_249_315
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var ErrNoMigrationApplied = fmt.Errorf("no migration is applied")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration with the time it was applied at, zero if it is pending.
+type Status struct {
+	*Migration
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	client     postgres.Client
+	migrations []*Migration
+}
+
+// New reads the migrations from the root directory of fsys.
+func New(client postgres.Client, fsys fs.FS, root string) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return nil, fmt.Errorf("migrate New: %v", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrate New: migration %d has different names %s and %s", version, m.Name, match[2])
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(root, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("migrate New: %v", err)
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migrate New: migration %d_%s has no up or down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return &Migrator{
+		client:     client,
+		migrations: migrations,
+	}, nil
+}
+
+// Up applies all pending migrations in order and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	err := m.createTable(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Migrator Up: %v", err)
+	}
+
+	applied := make([]*Migration, 0)
+	for {
+		migration, err := m.step(ctx, true)
+		if err != nil {
+			return applied, fmt.Errorf("Migrator Up: %v", err)
+		}
+		if migration == nil {
+			return applied, nil
+		}
+
+		applied = append(applied, migration)
+	}
+}
+
+// Down reverts the last applied migration and returns it.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	err := m.createTable(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Migrator Down: %v", err)
+	}
+
+	migration, err := m.step(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("Migrator Down: %v", err)
+	}
+	if migration == nil {
+		return nil, ErrNoMigrationApplied
+	}
+
+	return migration, nil
+}
+
+// step applies the first pending migration or reverts the last applied one in
+// a transaction holding the migration lock. It returns nil if there is nothing to do.
+func (m *Migrator) step(ctx context.Context, up bool) (*Migration, error) {
+	tx, err := m.client.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, lockId)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var migration *Migration
+	if up {
+		for _, mg := range m.migrations {
+			if _, ok := applied[mg.Version]; !ok {
+				migration = mg
+				break
+			}
+		}
+	} else {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				migration = m.migrations[i]
+				break
+			}
+		}
+	}
+	if migration == nil {
+		return nil, nil
+	}
+
+	if up {
+		_, err = tx.Exec(ctx, migration.Up)
+		if err != nil {
+			return nil, fmt.Errorf("migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+	} else {
+		_, err = tx.Exec(ctx, migration.Down)
+		if err != nil {
+			return nil, fmt.Errorf("migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return migration, nil
+}
+
+// Status returns all known migrations in order with the times they were applied at.
+// Like Pending, it does not create schema_migrations and reports all migrations as
+// pending for an empty database.
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	exists, err := m.tableExists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Migrator Status: %v", err)
+	}
+
+	applied := make(map[int]time.Time)
+	if exists {
+		applied, err = appliedVersions(ctx, m.client)
+		if err != nil {
+			return nil, fmt.Errorf("Migrator Status: %v", err)
+		}
+	}
+
+	statuses := make([]*Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, &Status{
+			Migration: migration,
+			AppliedAt: applied[migration.Version],
+		})
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the migrations that are not applied yet. It does not create
+// schema_migrations, so all migrations are pending for an empty database.
+func (m *Migrator) Pending(ctx context.Context) ([]*Migration, error) {
+	exists, err := m.tableExists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Migrator Pending: %v", err)
+	}
+	if !exists {
+		return m.migrations, nil
+	}
+
+	applied, err := appliedVersions(ctx, m.client)
+	if err != nil {
+		return nil, fmt.Errorf("Migrator Pending: %v", err)
+	}
+
+	pending := make([]*Migration, 0)
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// tableExists reports whether schema_migrations exists without creating it.
+func (m *Migrator) tableExists(ctx context.Context) (bool, error) {
+	var exists bool
+	err := m.client.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	return exists, err
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    int primary key,
+		name       text not null,
+		applied_at timestamptz not null default now()
+	)`
+
+	_, err := m.client.Exec(ctx, query)
+	return err
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// appliedVersions maps the versions of the applied migrations to the times they were applied at.
+func appliedVersions(ctx context.Context, q querier) (map[int]time.Time, error) {
+	rows, err := q.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,276 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/010_genres.up.sql":   {Data: []byte("create table genres ();")},
+		"migrations/010_genres.down.sql": {Data: []byte("drop table genres;")},
+		"migrations/002_films.up.sql":    {Data: []byte("create table films ();")},
+		"migrations/002_films.down.sql":  {Data: []byte("drop table films;")},
+		"migrations/001_init.up.sql":     {Data: []byte("create table users ();")},
+		"migrations/001_init.down.sql":   {Data: []byte("drop table users;")},
+		"migrations/README.md":           {Data: []byte("not a migration")},
+		"migrations/003_old/up.sql":      {Data: []byte("not a migration either")},
+	}
+}
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fsys     fstest.MapFS
+		want     []*Migration
+		wantErr  bool
+		errorMsg string
+	}{
+		{
+			name: "OK",
+			fsys: testFS(),
+			want: []*Migration{
+				{Version: 1, Name: "init", Up: "create table users ();", Down: "drop table users;"},
+				{Version: 2, Name: "films", Up: "create table films ();", Down: "drop table films;"},
+				{Version: 10, Name: "genres", Up: "create table genres ();", Down: "drop table genres;"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "no down file",
+			fsys: fstest.MapFS{
+				"migrations/001_init.up.sql": {Data: []byte("create table users ();")},
+			},
+			want:     nil,
+			wantErr:  true,
+			errorMsg: "migrate New: migration 1_init has no up or down file",
+		},
+		{
+			name: "different names",
+			fsys: fstest.MapFS{
+				"migrations/001_init.down.sql": {Data: []byte("drop table users;")},
+				"migrations/001_users.up.sql":  {Data: []byte("create table users ();")},
+			},
+			want:     nil,
+			wantErr:  true,
+			errorMsg: "migrate New: migration 1 has different names init and users",
+		},
+		{
+			name:     "no directory",
+			fsys:     fstest.MapFS{},
+			want:     nil,
+			wantErr:  true,
+			errorMsg: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+
+			got, err := New(poolMock, tc.fsys, "migrations")
+			if tc.wantErr {
+				assert.Error(t, err)
+				if tc.errorMsg != "" {
+					assert.EqualError(t, err, tc.errorMsg)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got.migrations)
+		})
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	poolMock, _ := pgxmock.NewPool()
+	defer poolMock.Close()
+
+	migrator, err := New(poolMock, testFS(), "migrations")
+	assert.NoError(t, err)
+
+	appliedAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	poolMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	poolMock.ExpectBegin()
+	poolMock.ExpectExec("SELECT pg_advisory_xact_lock\\(\\$1\\)").
+		WithArgs(lockId).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	poolMock.ExpectQuery("SELECT version, applied_at FROM schema_migrations").
+		WillReturnRows(pgxmock.NewRows([]string{"version", "applied_at"}).
+			AddRow(1, appliedAt).
+			AddRow(2, appliedAt))
+	poolMock.ExpectExec("create table genres \\(\\);").
+		WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	poolMock.ExpectExec("INSERT INTO schema_migrations \\(version, name\\) VALUES \\(\\$1, \\$2\\)").
+		WithArgs(10, "genres").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	poolMock.ExpectCommit()
+	poolMock.ExpectBegin()
+	poolMock.ExpectExec("SELECT pg_advisory_xact_lock").
+		WithArgs(lockId).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	poolMock.ExpectQuery("SELECT version, applied_at FROM schema_migrations").
+		WillReturnRows(pgxmock.NewRows([]string{"version", "applied_at"}).
+			AddRow(1, appliedAt).
+			AddRow(2, appliedAt).
+			AddRow(10, appliedAt))
+	poolMock.ExpectRollback()
+
+	got, err := migrator.Up(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []*Migration{migrator.migrations[2]}, got)
+
+	assert.NoError(t, poolMock.ExpectationsWereMet())
+}
+
+func TestMigrator_Pending(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	appliedAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         []int
+		wantErr      bool
+	}{
+		{
+			name: "empty database",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT to_regclass\\('schema_migrations'\\) IS NOT NULL").
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			want:    []int{1, 2, 10},
+			wantErr: false,
+		},
+		{
+			name: "some applied",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT to_regclass").
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+				m.ExpectQuery("SELECT version, applied_at FROM schema_migrations").
+					WillReturnRows(pgxmock.NewRows([]string{"version", "applied_at"}).AddRow(2, appliedAt))
+			},
+			want:    []int{1, 10},
+			wantErr: false,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT to_regclass").
+					WillReturnError(errors.New("some error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			migrator, err := New(poolMock, testFS(), "migrations")
+			assert.NoError(t, err)
+
+			got, err := migrator.Pending(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, versions(got))
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMigrator_Status(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	appliedAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		mockBehavior  MockBehavior
+		wantAppliedAt []time.Time
+		wantErr       bool
+	}{
+		{
+			name: "empty database",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT to_regclass\\('schema_migrations'\\) IS NOT NULL").
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			wantAppliedAt: []time.Time{{}, {}, {}},
+			wantErr:       false,
+		},
+		{
+			name: "some applied",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT to_regclass").
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+				m.ExpectQuery("SELECT version, applied_at FROM schema_migrations").
+					WillReturnRows(pgxmock.NewRows([]string{"version", "applied_at"}).AddRow(1, appliedAt))
+			},
+			wantAppliedAt: []time.Time{appliedAt, {}, {}},
+			wantErr:       false,
+		},
+		{
+			name: "unexpected error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT to_regclass").
+					WillReturnError(errors.New("some error"))
+			},
+			wantAppliedAt: nil,
+			wantErr:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			migrator, err := New(poolMock, testFS(), "migrations")
+			assert.NoError(t, err)
+
+			got, err := migrator.Status(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			want := make([]*Status, 0, len(tc.wantAppliedAt))
+			for i, at := range tc.wantAppliedAt {
+				want = append(want, &Status{Migration: migrator.migrations[i], AppliedAt: at})
+			}
+			assert.Equal(t, want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func versions(migrations []*Migration) []int {
+	result := make([]int, 0, len(migrations))
+	for _, m := range migrations {
+		result = append(result, m.Version)
+	}
+
+	return result
+}
@@ -0,0 +1,126 @@
+// Package password hashes passwords with argon2id and a random salt per
+// password. A hash is encoded in the PHC string format together with its
+// parameters, e.g. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>, so that
+// hashes made with older parameters still verify.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+const prefix = "$argon2id$"
+
+// Parameters of new hashes, the ones recommended by RFC 9106 for limited memory.
+const (
+	memoryKiB  = 64 * 1024
+	iterations = 3
+	threads    = 4
+	saltLen    = 16
+	keyLen     = 32
+)
+
+// maxConcurrent bounds the number of hashes computed at once. Every hash takes
+// memoryKiB of memory, so a burst of sign-ins must not compute them all at once.
+const maxConcurrent = 4
+
+var slots = make(chan struct{}, maxConcurrent)
+
+var ErrInvalidHash = fmt.Errorf("invalid password hash")
+
+type params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+var defaultParams = params{memory: memoryKiB, time: iterations, threads: threads}
+
+// Hash returns the encoded argon2id hash of the password with a new random salt.
+func Hash(password string) (string, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("password Hash: %v", err)
+	}
+
+	return hash(password, salt, defaultParams), nil
+}
+
+func hash(password string, salt []byte, p params) string {
+	key := idKey(password, salt, p, keyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", prefix, argon2.Version, p.memory, p.time, p.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// idKey computes an argon2id key waiting for a free slot if maxConcurrent keys
+// are being computed.
+func idKey(password string, salt []byte, p params, keyLen uint32) []byte {
+	slots <- struct{}{}
+	defer func() { <-slots }()
+
+	return argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, keyLen)
+}
+
+// IsHash reports whether encoded is an argon2id hash made by Hash, as opposed
+// to a hash of a legacy scheme.
+func IsHash(encoded string) bool {
+	return strings.HasPrefix(encoded, prefix)
+}
+
+// Verify reports whether the password matches the encoded hash in constant time.
+func Verify(password, encoded string) (bool, error) {
+	p, salt, key, err := decode(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := idKey(password, salt, p, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash reports whether the encoded hash was made with parameters other
+// than the current ones and should be replaced after a successful Verify.
+func NeedsRehash(encoded string) bool {
+	p, _, key, err := decode(encoded)
+	return err != nil || p != defaultParams || len(key) != keyLen
+}
+
+func decode(encoded string) (params, []byte, []byte, error) {
+	var (
+		p       params
+		version int
+	)
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || !IsHash(encoded) {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	// argon2 panics on zero time or threads instead of returning an error
+	if p.time < 1 || p.threads < 1 {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,104 @@
+package password
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHashVerify(t *testing.T) {
+	encoded, err := Hash("secret")
+	assert.NoError(t, err)
+	assert.True(t, IsHash(encoded))
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=65536,t=3,p=4$"))
+
+	ok, err := Verify("secret", encoded)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = Verify("Secret", encoded)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	other, err := Hash("secret")
+	assert.NoError(t, err)
+	assert.NotEqual(t, encoded, other, "salt must be random")
+}
+
+func TestVerify_InvalidHash(t *testing.T) {
+	testCases := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "legacy hash", encoded: "6e6d6c4f7a6b5a3132332e"},
+		{name: "empty", encoded: ""},
+		{name: "missing part", encoded: "$argon2id$v=19$m=65536,t=3,p=4$c2FsdHNhbHRzYWx0c2FsdA"},
+		{name: "other version", encoded: "$argon2id$v=16$m=65536,t=3,p=4$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{name: "bad parameters", encoded: "$argon2id$v=19$m=65536,t=x,p=4$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{name: "zero time", encoded: "$argon2id$v=19$m=65536,t=0,p=4$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{name: "zero threads", encoded: "$argon2id$v=19$m=65536,t=3,p=0$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{name: "bad salt", encoded: "$argon2id$v=19$m=65536,t=3,p=4$!!!$a2V5"},
+		{name: "empty key", encoded: "$argon2id$v=19$m=65536,t=3,p=4$c2FsdHNhbHRzYWx0c2FsdA$"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := Verify("secret", tc.encoded)
+			assert.Equal(t, ErrInvalidHash, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	salt := []byte("saltsaltsaltsalt")
+
+	current, err := Hash("secret")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		encoded string
+		want    bool
+	}{
+		{name: "current parameters", encoded: current, want: false},
+		{name: "fewer iterations", encoded: hash("secret", salt, params{memory: memoryKiB, time: 1, threads: threads}), want: true},
+		{name: "less memory", encoded: hash("secret", salt, params{memory: 32 * 1024, time: iterations, threads: threads}), want: true},
+		{name: "other threads", encoded: hash("secret", salt, params{memory: memoryKiB, time: iterations, threads: 2}), want: true},
+		{name: "invalid hash", encoded: "6e6d6c4f7a6b5a3132332e", want: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, NeedsRehash(tc.encoded))
+		})
+	}
+}
+
+func TestVerify_OldParameters(t *testing.T) {
+	encoded := hash("secret", []byte("saltsaltsaltsalt"), params{memory: 32 * 1024, time: 1, threads: 2})
+
+	ok, err := Verify("secret", encoded)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestVerify_Concurrent(t *testing.T) {
+	encoded, err := Hash("secret")
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2*maxConcurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := Verify("secret", encoded)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, slots, 0)
+}